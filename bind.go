@@ -7,41 +7,188 @@ package globodns
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 )
 
+const DefaultExportPollInterval = 5 * time.Second
+
 type BindService interface {
 	Export(ctx context.Context) (*ScheduleExport, error)
+	ExportNow(ctx context.Context) (*ImmediateExport, error)
+	LastExport(ctx context.Context) (*ExportStatus, error)
+	Wait(ctx context.Context, se *ScheduleExport) (*ExportStatus, error)
+}
+
+// scheduleDateLayout is the layout used by GloboDNS to format dates.
+const scheduleDateLayout = "2006-01-02 15:04:05 -0700"
+
+// dateLayouts lists, in order of preference, every date layout returned by
+// the known GloboDNS versions. Layouts without offset are parsed in the local
+// time zone.
+var dateLayouts = []string{
+	scheduleDateLayout,
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
 }
 
 type ScheduleExport struct {
 	Output       string
 	ScheduleDate time.Time
+
+	// Extra holds the fields sent by GloboDNS which are not mapped above.
+	Extra map[string]json.RawMessage
 }
 
 func (se *ScheduleExport) UnmarshalJSON(data []byte) error {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+
+	for key, value := range object {
+		switch key {
+		case "output":
+			var output *string
+			if err := json.Unmarshal(value, &output); err != nil {
+				return err
+			}
+
+			se.Output = StringValue(output)
+
+		case "schedule_date":
+			var dateStr *string
+			if err := json.Unmarshal(value, &dateStr); err != nil {
+				return err
+			}
+
+			if dateStr == nil {
+				continue
+			}
+
+			t, err := parseDate(*dateStr)
+			if err != nil {
+				return err
+			}
+
+			se.ScheduleDate = t
+
+		default:
+			if se.Extra == nil {
+				se.Extra = make(map[string]json.RawMessage)
+			}
+
+			se.Extra[key] = value
+		}
+	}
+
+	return nil
+}
+
+func (se ScheduleExport) MarshalJSON() ([]byte, error) {
+	object := make(map[string]interface{})
+	for key, value := range se.Extra {
+		object[key] = value
+	}
+
+	object["output"] = se.Output
+
+	if !se.ScheduleDate.IsZero() {
+		object["schedule_date"] = se.ScheduleDate.Format(scheduleDateLayout)
+	}
+
+	return json.Marshal(object)
+}
+
+func parseDate(s string) (time.Time, error) {
+	var firstErr error
+
+	for _, layout := range dateLayouts {
+		t, err := time.ParseInLocation(layout, s, time.Local)
+		if err == nil {
+			return t, nil
+		}
+
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return time.Time{}, firstErr
+}
+
+type ImmediateExport struct {
+	Output   string
+	Zones    []string
+	Errors   []string
+	Duration time.Duration
+	Report   *ExportReport
+}
+
+type ExportStatus struct {
+	Status       string
+	Output       string
+	LastExport   *time.Time
+	ScheduleDate *time.Time
+}
+
+// Succeeded tells whether the export is known to have succeeded, so an
+// empty status, which is unknown, is not.
+func (es *ExportStatus) Succeeded() bool {
+	switch strings.ToLower(es.Status) {
+	case "ok", "success", "succeeded":
+		return true
+	}
+
+	return false
+}
+
+func (es *ExportStatus) UnmarshalJSON(data []byte) error {
 	var object map[string]interface{}
 	if err := json.Unmarshal(data, &object); err != nil {
 		return err
 	}
 
+	if status, ok := object["status"].(string); ok {
+		es.Status = status
+	}
+
 	if output, ok := object["output"].(string); ok {
-		se.Output = output
+		es.Output = output
+	}
+
+	if dateStr, ok := object["last_export"].(string); ok {
+		t, err := parseDate(dateStr)
+		if err != nil {
+			return err
+		}
+
+		es.LastExport = &t
 	}
 
 	if dateStr, ok := object["schedule_date"].(string); ok {
-		t, err := time.Parse("2006-01-02 15:04:05 -0700", dateStr)
+		t, err := parseDate(dateStr)
 		if err != nil {
 			return err
 		}
 
-		se.ScheduleDate = t
+		es.ScheduleDate = &t
 	}
 
 	return nil
 }
 
+type ExportError struct {
+	Status *ExportStatus
+}
+
+func (e *ExportError) Error() string {
+	return fmt.Sprintf("globodns: bind export finished with status %q: %s", e.Status.Status, e.Status.Output)
+}
+
 var _ BindService = &bindService{}
 
 func NewBindService(c *Client) BindService {
@@ -66,3 +213,106 @@ func (b *bindService) Export(ctx context.Context) (*ScheduleExport, error) {
 
 	return &se, nil
 }
+
+func (b *bindService) ExportNow(ctx context.Context) (*ImmediateExport, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", b.makeURL("/bind9/export.json?now=true"), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var got struct {
+		Output string `json:"output"`
+	}
+
+	started := time.Now()
+
+	_, err = b.Do(req, &got)
+	if err != nil {
+		return nil, err
+	}
+
+	ie := parseExportOutput(got.Output)
+	ie.Duration = time.Since(started)
+
+	return ie, nil
+}
+
+func (b *bindService) LastExport(ctx context.Context) (*ExportStatus, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", b.makeURL("/bind9/export_status.json"), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var es ExportStatus
+	_, err = b.Do(req, &es)
+	if err != nil {
+		return nil, err
+	}
+
+	return &es, nil
+}
+
+func (b *bindService) Wait(ctx context.Context, se *ScheduleExport) (*ExportStatus, error) {
+	if se == nil {
+		return nil, fmt.Errorf("globodns: schedule export cannot be nil")
+	}
+
+	if se.ScheduleDate.IsZero() {
+		return nil, fmt.Errorf("globodns: schedule export has no schedule date")
+	}
+
+	return b.wait(ctx, se)
+}
+
+// wait polls the last export, without ever triggering one, until an export
+// finished at or after the schedule date of se.
+func (b *bindService) wait(ctx context.Context, se *ScheduleExport) (*ExportStatus, error) {
+	ticker := time.NewTicker(b.pollInterval())
+	defer ticker.Stop()
+
+	for {
+		es, err := b.LastExport(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if es.LastExport != nil && !es.LastExport.Before(se.ScheduleDate) {
+			if !es.Succeeded() {
+				return es, &ExportError{Status: es}
+			}
+
+			return es, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *bindService) pollInterval() time.Duration {
+	if b.exportPollInterval > 0 {
+		return b.exportPollInterval
+	}
+
+	return DefaultExportPollInterval
+}
+
+func parseExportOutput(output string) *ImmediateExport {
+	report := ParseExportOutput(output)
+	ie := &ImmediateExport{Output: output, Report: report}
+
+	for _, z := range report.Zones {
+		if z.Status == ZoneStatusLoaded {
+			ie.Zones = append(ie.Zones, z.Name)
+		}
+	}
+
+	for _, e := range report.Errors {
+		ie.Errors = append(ie.Errors, e.Text)
+	}
+
+	return ie
+}
@@ -0,0 +1,139 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package globodns
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrExportCoordinatorClosed = errors.New("globodns: export coordinator is closed")
+
+type ExportResult struct {
+	Export *ScheduleExport
+	Err    error
+}
+
+// ExportCoordinator coalesces the export requests made by many goroutines,
+// calling BindService.Export once the window elapses after the first pending
+// request. So Export is called at most once per window, and every request
+// waiting at that moment gets the same result.
+type ExportCoordinator struct {
+	bind   BindService
+	window time.Duration
+
+	mu      sync.Mutex
+	waiters []chan ExportResult
+	timer   *time.Timer
+	closed  bool
+	running sync.WaitGroup
+}
+
+func NewExportCoordinator(b BindService, window time.Duration) *ExportCoordinator {
+	return &ExportCoordinator{bind: b, window: window}
+}
+
+// Trigger signals that an export is needed. The returned channel receives
+// the result of the export that covers this signal.
+func (c *ExportCoordinator) Trigger() <-chan ExportResult {
+	ch := make(chan ExportResult, 1)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		ch <- ExportResult{Err: ErrExportCoordinatorClosed}
+		return ch
+	}
+
+	c.waiters = append(c.waiters, ch)
+
+	if c.timer == nil {
+		c.timer = time.AfterFunc(c.window, func() {
+			c.flush(context.Background())
+		})
+	}
+
+	return ch
+}
+
+// Request triggers an export and waits for its result.
+func (c *ExportCoordinator) Request(ctx context.Context) (*ScheduleExport, error) {
+	select {
+	case r := <-c.Trigger():
+		return r.Export, r.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close stops accepting new signals, exports right away whatever is still
+// pending and waits for any export in progress to finish.
+func (c *ExportCoordinator) Close(ctx context.Context) error {
+	if waiters := c.take(true); len(waiters) > 0 {
+		c.export(ctx, waiters)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// flush exports for the pending signals once the window elapses. It does
+// nothing once the coordinator is closed, as Close takes care of them.
+func (c *ExportCoordinator) flush(ctx context.Context) {
+	if waiters := c.take(false); len(waiters) > 0 {
+		c.export(ctx, waiters)
+	}
+}
+
+// take removes the pending signals, marking the coordinator closed and
+// stopping the timer when closing. An export is accounted as running in the
+// same critical section, so Close never waits before it's added.
+func (c *ExportCoordinator) take(closing bool) []chan ExportResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed && !closing {
+		return nil
+	}
+
+	if closing {
+		c.closed = true
+		if c.timer != nil {
+			c.timer.Stop()
+		}
+	}
+
+	waiters := c.waiters
+	c.waiters = nil
+	c.timer = nil
+
+	if len(waiters) > 0 {
+		c.running.Add(1)
+	}
+
+	return waiters
+}
+
+func (c *ExportCoordinator) export(ctx context.Context, waiters []chan ExportResult) {
+	defer c.running.Done()
+
+	se, err := c.bind.Export(ctx)
+	for _, ch := range waiters {
+		ch <- ExportResult{Export: se, Err: err}
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package globodns_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/fake"
+)
+
+func TestExportCoordinator(t *testing.T) {
+	var calls int32
+
+	bind := &fake.FakeBindService{
+		FakeExport: func(ctx context.Context) (*globodns.ScheduleExport, error) {
+			n := atomic.AddInt32(&calls, 1)
+			return &globodns.ScheduleExport{Output: "BIND export scheduled", ScheduleDate: time.Unix(int64(n), 0)}, nil
+		},
+	}
+
+	c := globodns.NewExportCoordinator(bind, 50*time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			se, err := c.Request(context.TODO())
+			assert.NoError(t, err)
+			assert.Equal(t, &globodns.ScheduleExport{Output: "BIND export scheduled", ScheduleDate: time.Unix(1, 0)}, se)
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	se, err := c.Request(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(2, 0), se.ScheduleDate)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestExportCoordinator_Close(t *testing.T) {
+	var calls int32
+
+	bind := &fake.FakeBindService{
+		FakeExport: func(ctx context.Context) (*globodns.ScheduleExport, error) {
+			atomic.AddInt32(&calls, 1)
+			return &globodns.ScheduleExport{Output: "BIND export scheduled"}, nil
+		},
+	}
+
+	c := globodns.NewExportCoordinator(bind, time.Hour)
+
+	first, second := c.Trigger(), c.Trigger()
+
+	err := c.Close(context.TODO())
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "pending signals should be flushed on close")
+	assert.Equal(t, globodns.ExportResult{Export: &globodns.ScheduleExport{Output: "BIND export scheduled"}}, <-first)
+	assert.Equal(t, globodns.ExportResult{Export: &globodns.ScheduleExport{Output: "BIND export scheduled"}}, <-second)
+
+	_, err = c.Request(context.TODO())
+	assert.Equal(t, globodns.ErrExportCoordinatorClosed, err)
+}
+
+func TestExportCoordinator_CloseWhileFlushing(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		var calls int32
+
+		bind := &fake.FakeBindService{
+			FakeExport: func(ctx context.Context) (*globodns.ScheduleExport, error) {
+				atomic.AddInt32(&calls, 1)
+				return &globodns.ScheduleExport{Output: "BIND export scheduled"}, nil
+			},
+		}
+
+		c := globodns.NewExportCoordinator(bind, time.Microsecond)
+		ch := c.Trigger()
+
+		err := c.Close(context.TODO())
+		require.NoError(t, err)
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "signal should be exported exactly once")
+		assert.Equal(t, globodns.ExportResult{Export: &globodns.ScheduleExport{Output: "BIND export scheduled"}}, <-ch)
+	}
+}
@@ -0,0 +1,277 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package globodns
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	ToolNamedCheckConf = "named-checkconf"
+	ToolNamedCheckZone = "named-checkzone"
+)
+
+const (
+	ZoneStatusLoaded = "loaded"
+	ZoneStatusFailed = "failed"
+)
+
+type ExportReport struct {
+	Zones        []ZoneReport
+	Errors       []CheckError
+	ChangedFiles []string
+
+	// Warnings are the messages about a file or zone which do not fail the
+	// check, e.g. "TTL set to prior TTL".
+	Warnings []CheckError
+}
+
+type ZoneReport struct {
+	Name     string
+	Status   string
+	Serial   string
+	Errors   []CheckError
+	Warnings []CheckError
+}
+
+type CheckError struct {
+	Tool    string
+	Zone    string
+	File    string
+	Line    int
+	Message string
+
+	// Text is the line of the output where the error was found.
+	Text string
+}
+
+func (r *ExportReport) Failed() bool {
+	return len(r.Errors) > 0
+}
+
+func (r *ExportReport) Zone(name string) *ZoneReport {
+	name = strings.TrimSuffix(name, ".")
+	for i := range r.Zones {
+		if r.Zones[i].Name == name {
+			return &r.Zones[i]
+		}
+	}
+
+	return nil
+}
+
+var (
+	// e.g. "zone example.com/IN: loaded serial 2021102901"
+	zoneLoadedRegexp = regexp.MustCompile(`^zone ([^/\s]+)(?:/[A-Z]+)?: loaded serial (\d+)`)
+
+	// e.g. "zone example.com/IN: loading from master file db.example.com failed: unknown class/type"
+	zoneMessageRegexp = regexp.MustCompile(`^zone ([^/\s]+)(?:/[A-Z]+)?: (.+)$`)
+
+	// e.g. "dns_master_load: db.example.com:12: unknown RR type 'AA'",
+	// "/etc/named.conf:23: unknown option 'foo'" or
+	// "db.example.com:5: TTL set to prior TTL (3600)"
+	fileLineRegexp = regexp.MustCompile(`^(?:[\w-]+: )?([^\s:]+):(\d+): (.+)$`)
+
+	// e.g. "[master 1a2b3c4] Bind export", " 2 files changed, 4 insertions(+)"
+	// or "Changes to be committed:", starting the git section of the output.
+	gitHeaderRegexp = regexp.MustCompile(`^\[[\w./-]+(?: \([\w-]+\))? [0-9a-f]{7,40}\]|^\[[\w./-]+\] [0-9a-f]{7,40}\b|^\d+ files? changed\b|^(?:On branch \S+|Changes to be committed:|Changes not staged for commit:|Untracked files:)$`)
+
+	// e.g. " create mode 100644 zones/db.example.com" or " rewrite zones/db.example.com (60%)"
+	gitModeRegexp = regexp.MustCompile(`^(?:create|delete) mode \d+ (\S+)$|^rewrite (\S+) \(\d+%\)$`)
+
+	// e.g. " zones/db.example.com | 4 ++--"
+	gitStatRegexp = regexp.MustCompile(`^(\S+)\s+\|\s+(?:\d+|Bin)`)
+
+	// e.g. "M  zones/db.example.com"
+	gitStatusRegexp = regexp.MustCompile(`^[MADR?]{1,2}\s+(\S+)$`)
+)
+
+// ParseExportOutput extracts the zones checked, the errors and warnings
+// reported by named-checkzone and named-checkconf and the files changed from the log of a
+// bind export. The output is split in sections by blank lines and by headers,
+// i.e. lines mentioning a tool or starting git output; changed files are
+// only taken from git sections.
+func ParseExportOutput(output string) *ExportReport {
+	p := &exportParser{report: &ExportReport{}, zones: make(map[string]int), files: make(map[string]bool)}
+
+	for _, line := range strings.Split(output, "\n") {
+		p.parseLine(strings.TrimSpace(line))
+	}
+
+	return p.report
+}
+
+type exportParser struct {
+	report *ExportReport
+	zones  map[string]int
+	files  map[string]bool
+
+	// tool and git tell the section being parsed.
+	tool string
+	git  bool
+}
+
+func (p *exportParser) parseLine(line string) {
+	if line == "" {
+		p.tool, p.git = "", false
+		return
+	}
+
+	if gitHeaderRegexp.MatchString(line) {
+		p.tool, p.git = "", true
+		return
+	}
+
+	if strings.Contains(line, ToolNamedCheckConf) {
+		p.tool, p.git = ToolNamedCheckConf, false
+	} else if strings.Contains(line, ToolNamedCheckZone) {
+		p.tool, p.git = ToolNamedCheckZone, false
+	}
+
+	if m := zoneLoadedRegexp.FindStringSubmatch(line); m != nil {
+		z := p.zone(m[1])
+		if z.Status == "" {
+			z.Status = ZoneStatusLoaded
+		}
+		z.Serial = m[2]
+		return
+	}
+
+	if m := fileLineRegexp.FindStringSubmatch(line); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		e := CheckError{File: m[1], Line: n, Message: m[3], Text: line}
+		if isErrorLine(line) {
+			p.addError(e)
+		} else {
+			p.addWarning(e)
+		}
+		return
+	}
+
+	if m := zoneMessageRegexp.FindStringSubmatch(line); m != nil {
+		e := CheckError{Tool: ToolNamedCheckZone, Zone: m[1], Message: m[2], Text: line}
+		if isErrorMessage(m[2]) {
+			p.addError(e)
+		} else {
+			p.addWarning(e)
+		}
+		return
+	}
+
+	if p.git && p.parseGitLine(line) {
+		return
+	}
+
+	if isErrorMessage(line) {
+		p.addError(CheckError{Tool: p.tool, Message: line, Text: line})
+	}
+}
+
+func (p *exportParser) parseGitLine(line string) bool {
+	if m := gitModeRegexp.FindStringSubmatch(line); m != nil {
+		p.addFile(m[1] + m[2])
+		return true
+	}
+
+	for _, re := range []*regexp.Regexp{gitStatRegexp, gitStatusRegexp} {
+		if m := re.FindStringSubmatch(line); m != nil {
+			p.addFile(m[1])
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *exportParser) addError(e CheckError) {
+	e = p.locate(e)
+	p.report.Errors = append(p.report.Errors, e)
+
+	if e.Zone == "" {
+		return
+	}
+
+	z := p.zone(e.Zone)
+	z.Status = ZoneStatusFailed
+	z.Errors = append(z.Errors, e)
+}
+
+// addWarning records e without failing its zone.
+func (p *exportParser) addWarning(e CheckError) {
+	e = p.locate(e)
+	p.report.Warnings = append(p.report.Warnings, e)
+
+	if e.Zone == "" {
+		return
+	}
+
+	z := p.zone(e.Zone)
+	z.Warnings = append(z.Warnings, e)
+}
+
+// locate fills the tool and the zone of e, when missing, from the section
+// being parsed and the file mentioned.
+func (p *exportParser) locate(e CheckError) CheckError {
+	if e.Tool == "" {
+		e.Tool = p.tool
+	}
+
+	if e.File != "" && e.Tool == "" {
+		e.Tool = ToolNamedCheckZone
+		if strings.HasSuffix(e.File, ".conf") {
+			e.Tool = ToolNamedCheckConf
+		}
+	}
+
+	if e.Zone == "" && e.File != "" && e.Tool != ToolNamedCheckConf {
+		e.Zone = zoneFromFile(e.File)
+	}
+
+	return e
+}
+
+func (p *exportParser) addFile(name string) {
+	if p.files[name] {
+		return
+	}
+
+	p.files[name] = true
+	p.report.ChangedFiles = append(p.report.ChangedFiles, name)
+}
+
+func (p *exportParser) zone(name string) *ZoneReport {
+	name = strings.TrimSuffix(name, ".")
+
+	i, ok := p.zones[name]
+	if !ok {
+		i = len(p.report.Zones)
+		p.zones[name] = i
+		p.report.Zones = append(p.report.Zones, ZoneReport{Name: name})
+	}
+
+	return &p.report.Zones[i]
+}
+
+// zoneFromFile guesses the zone name from its master file, which is usually
+// named after the zone, optionally prefixed by "db.".
+func zoneFromFile(file string) string {
+	name := strings.TrimPrefix(filepath.Base(file), "db.")
+	return strings.TrimSuffix(name, ".zone")
+}
+
+// isErrorLine tells whether a line mentioning a file and line number reports
+// an error, rather than a warning such as "has no address records".
+func isErrorLine(s string) bool {
+	s = strings.ToLower(s)
+	return strings.Contains(s, ": error") || strings.Contains(s, "not loaded due to errors") || strings.Contains(s, "failed")
+}
+
+func isErrorMessage(s string) bool {
+	s = strings.ToLower(s)
+	return strings.Contains(s, "error") || strings.Contains(s, "failed") || strings.Contains(s, "not loaded")
+}
@@ -0,0 +1,191 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package globodns_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+)
+
+func TestParseExportOutput(t *testing.T) {
+	tests := map[string]struct {
+		output   string
+		expected *globodns.ExportReport
+	}{
+		"empty output": {
+			expected: &globodns.ExportReport{},
+		},
+
+		"zones loaded and files changed": {
+			output: `[master] 1a2b3c4 Bind export
+ 2 files changed, 4 insertions(+), 2 deletions(-)
+ create mode 100644 zones/db.example.org
+ zones/db.example.com | 4 ++--
+zone example.com/IN: loaded serial 2021102901
+zone example.org/IN: loaded serial 2021102902
+OK`,
+			expected: &globodns.ExportReport{
+				Zones: []globodns.ZoneReport{
+					{Name: "example.com", Status: globodns.ZoneStatusLoaded, Serial: "2021102901"},
+					{Name: "example.org", Status: globodns.ZoneStatusLoaded, Serial: "2021102902"},
+				},
+				ChangedFiles: []string{"zones/db.example.org", "zones/db.example.com"},
+			},
+		},
+
+		"named-checkzone errors": {
+			output: `Running named-checkzone
+zone example.com/IN: loaded serial 2021102901
+dns_master_load: zones/db.example.org:12: unknown RR type 'AA'
+zone example.org/IN: loading from master file zones/db.example.org failed: unknown class/type
+zone example.org/IN: not loaded due to errors.`,
+			expected: &globodns.ExportReport{
+				Zones: []globodns.ZoneReport{
+					{Name: "example.com", Status: globodns.ZoneStatusLoaded, Serial: "2021102901"},
+					{
+						Name:   "example.org",
+						Status: globodns.ZoneStatusFailed,
+						Errors: []globodns.CheckError{
+							{Tool: "named-checkzone", Zone: "example.org", Message: "loading from master file zones/db.example.org failed: unknown class/type", Text: "zone example.org/IN: loading from master file zones/db.example.org failed: unknown class/type"},
+							{Tool: "named-checkzone", Zone: "example.org", Message: "not loaded due to errors.", Text: "zone example.org/IN: not loaded due to errors."},
+						},
+						Warnings: []globodns.CheckError{
+							{Tool: "named-checkzone", Zone: "example.org", File: "zones/db.example.org", Line: 12, Message: "unknown RR type 'AA'", Text: "dns_master_load: zones/db.example.org:12: unknown RR type 'AA'"},
+						},
+					},
+				},
+				Errors: []globodns.CheckError{
+					{Tool: "named-checkzone", Zone: "example.org", Message: "loading from master file zones/db.example.org failed: unknown class/type", Text: "zone example.org/IN: loading from master file zones/db.example.org failed: unknown class/type"},
+					{Tool: "named-checkzone", Zone: "example.org", Message: "not loaded due to errors.", Text: "zone example.org/IN: not loaded due to errors."},
+				},
+				Warnings: []globodns.CheckError{
+					{Tool: "named-checkzone", Zone: "example.org", File: "zones/db.example.org", Line: 12, Message: "unknown RR type 'AA'", Text: "dns_master_load: zones/db.example.org:12: unknown RR type 'AA'"},
+				},
+			},
+		},
+
+		"named-checkzone warnings only": {
+			output: `Running named-checkzone
+zones/db.example.com:5: TTL set to prior TTL (3600)
+zone example.com/IN: ns.example.com has no address records (A or AAAA)
+zone example.com/IN: loaded serial 2021102901
+zones/db.example.org:7: example.org: has no address records
+zone example.org/IN: loaded serial 2021102902
+OK`,
+			expected: &globodns.ExportReport{
+				Zones: []globodns.ZoneReport{
+					{
+						Name:   "example.com",
+						Status: globodns.ZoneStatusLoaded,
+						Serial: "2021102901",
+						Warnings: []globodns.CheckError{
+							{Tool: "named-checkzone", Zone: "example.com", File: "zones/db.example.com", Line: 5, Message: "TTL set to prior TTL (3600)", Text: "zones/db.example.com:5: TTL set to prior TTL (3600)"},
+							{Tool: "named-checkzone", Zone: "example.com", Message: "ns.example.com has no address records (A or AAAA)", Text: "zone example.com/IN: ns.example.com has no address records (A or AAAA)"},
+						},
+					},
+					{
+						Name:   "example.org",
+						Status: globodns.ZoneStatusLoaded,
+						Serial: "2021102902",
+						Warnings: []globodns.CheckError{
+							{Tool: "named-checkzone", Zone: "example.org", File: "zones/db.example.org", Line: 7, Message: "example.org: has no address records", Text: "zones/db.example.org:7: example.org: has no address records"},
+						},
+					},
+				},
+				Warnings: []globodns.CheckError{
+					{Tool: "named-checkzone", Zone: "example.com", File: "zones/db.example.com", Line: 5, Message: "TTL set to prior TTL (3600)", Text: "zones/db.example.com:5: TTL set to prior TTL (3600)"},
+					{Tool: "named-checkzone", Zone: "example.com", Message: "ns.example.com has no address records (A or AAAA)", Text: "zone example.com/IN: ns.example.com has no address records (A or AAAA)"},
+					{Tool: "named-checkzone", Zone: "example.org", File: "zones/db.example.org", Line: 7, Message: "example.org: has no address records", Text: "zones/db.example.org:7: example.org: has no address records"},
+				},
+			},
+		},
+
+		"named-checkzone error marker on a file line": {
+			output: `zones/db.example.com:9: error: bad dotted quad
+zone example.com/IN: not loaded due to errors.`,
+			expected: &globodns.ExportReport{
+				Zones: []globodns.ZoneReport{
+					{
+						Name:   "example.com",
+						Status: globodns.ZoneStatusFailed,
+						Errors: []globodns.CheckError{
+							{Tool: "named-checkzone", Zone: "example.com", File: "zones/db.example.com", Line: 9, Message: "error: bad dotted quad", Text: "zones/db.example.com:9: error: bad dotted quad"},
+							{Tool: "named-checkzone", Zone: "example.com", Message: "not loaded due to errors.", Text: "zone example.com/IN: not loaded due to errors."},
+						},
+					},
+				},
+				Errors: []globodns.CheckError{
+					{Tool: "named-checkzone", Zone: "example.com", File: "zones/db.example.com", Line: 9, Message: "error: bad dotted quad", Text: "zones/db.example.com:9: error: bad dotted quad"},
+					{Tool: "named-checkzone", Zone: "example.com", Message: "not loaded due to errors.", Text: "zone example.com/IN: not loaded due to errors."},
+				},
+			},
+		},
+
+		"git status after a real commit line": {
+			output: `[master 1a2b3c4] Bind export
+M  zones/db.example.com
+?? zones/db.example.org
+
+zone example.com/IN: loaded serial 2021102901`,
+			expected: &globodns.ExportReport{
+				Zones: []globodns.ZoneReport{
+					{Name: "example.com", Status: globodns.ZoneStatusLoaded, Serial: "2021102901"},
+				},
+				ChangedFiles: []string{"zones/db.example.com", "zones/db.example.org"},
+			},
+		},
+
+		"named-checkzone output looking like git status": {
+			output: `Running named-checkzone
+zone example.com/IN: loaded serial 2021102901
+D  example.com
+A  www
+OK`,
+			expected: &globodns.ExportReport{
+				Zones: []globodns.ZoneReport{
+					{Name: "example.com", Status: globodns.ZoneStatusLoaded, Serial: "2021102901"},
+				},
+			},
+		},
+
+		"tool reset between sections": {
+			output: `Running named-checkconf
+ERROR: configuration check failed
+
+ERROR: export failed
+[master 1a2b3c4] Bind export
+error: could not push`,
+			expected: &globodns.ExportReport{
+				Errors: []globodns.CheckError{
+					{Tool: "named-checkconf", Message: "ERROR: configuration check failed", Text: "ERROR: configuration check failed"},
+					{Message: "ERROR: export failed", Text: "ERROR: export failed"},
+					{Message: "error: could not push", Text: "error: could not push"},
+				},
+			},
+		},
+
+		"named-checkconf errors": {
+			output: `/etc/named/named.conf:23: unknown option 'foo'
+ERROR: named-checkconf failed`,
+			expected: &globodns.ExportReport{
+				Errors: []globodns.CheckError{
+					{Tool: "named-checkconf", Message: "ERROR: named-checkconf failed", Text: "ERROR: named-checkconf failed"},
+				},
+				Warnings: []globodns.CheckError{
+					{Tool: "named-checkconf", File: "/etc/named/named.conf", Line: 23, Message: "unknown option 'foo'", Text: "/etc/named/named.conf:23: unknown option 'foo'"},
+				},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, globodns.ParseExportOutput(tt.output))
+		})
+	}
+}
@@ -6,6 +6,7 @@ package globodns_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -67,3 +68,291 @@ func TestClient_BindExport(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_BindWait(t *testing.T) {
+	var count int
+	past := time.Date(2021, 10, 29, 20, 43, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		handler         http.Handler
+		scheduleDate    *time.Time
+		timeout         time.Duration
+		expected        *globodns.ExportStatus
+		expectedError   string
+		expectedErrorIs error
+	}{
+		"export finished after a few polls": {
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer func() { count++ }()
+
+				assert.Equal(t, "GET", r.Method)
+				assert.Equal(t, "/bind9/export_status.json", r.URL.Path)
+
+				if count < 2 {
+					fmt.Fprintf(w, `{"status": "ok", "last_export": "2021-10-29 17:30:00 -0300", "schedule_date": "2021-10-29 17:43:00 -0300"}`)
+					return
+				}
+
+				fmt.Fprintf(w, `{"status": "ok", "output": "Zones exported", "last_export": "2021-10-29 17:43:05 -0300"}`)
+			}),
+			expected: &globodns.ExportStatus{
+				Status:     "ok",
+				Output:     "Zones exported",
+				LastExport: globodns.TimePointer(time.Date(2021, 10, 29, 20, 43, 5, 0, time.UTC)),
+			},
+		},
+
+		"export failed": {
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"status": "error", "output": "named-checkconf failed", "last_export": "2021-10-29 17:43:05 -0300"}`)
+			}),
+			expectedError: `globodns: bind export finished with status "error": named-checkconf failed`,
+		},
+
+		"export with unknown status": {
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"output": "done", "last_export": "2021-10-29 17:43:05 -0300"}`)
+			}),
+			expectedError: `globodns: bind export finished with status "": done`,
+		},
+
+		"missing schedule date": {
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				t.Errorf("unexpected request to %s", r.URL)
+			}),
+			scheduleDate:  &time.Time{},
+			expectedError: "globodns: schedule export has no schedule date",
+		},
+
+		"export did not run before timeout": {
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"status": "ok", "last_export": "2021-10-29 17:30:00 -0300"}`)
+			}),
+			timeout:         50 * time.Millisecond,
+			expectedErrorIs: context.DeadlineExceeded,
+		},
+
+		"server returns an error": {
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprintf(w, `{"error":"NOT AUTHORIZED"}`)
+			}),
+			expectedError: `globodns: unexpected HTTP status code: Code: 403 Body: {"error":"NOT AUTHORIZED"}`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			count = 0
+
+			// NOTE: Wait must only read the export status, never export.
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != "GET" {
+					t.Errorf("unexpected %s request to %s", r.Method, r.URL)
+				}
+
+				tt.handler.ServeHTTP(w, r)
+			}))
+			defer server.Close()
+
+			client, err := globodns.New(nil, server.URL)
+			require.NoError(t, err)
+			client.SetExportPollInterval(time.Millisecond)
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			se := &globodns.ScheduleExport{ScheduleDate: past}
+			if tt.scheduleDate != nil {
+				se.ScheduleDate = *tt.scheduleDate
+			}
+
+			got, err := client.Bind.Wait(ctx, se)
+			if tt.expectedErrorIs != nil {
+				assert.ErrorIs(t, err, tt.expectedErrorIs)
+				return
+			}
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, got.LastExport)
+			assert.True(t, tt.expected.LastExport.Equal(*got.LastExport))
+			got.LastExport = tt.expected.LastExport
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestExportStatus_Succeeded(t *testing.T) {
+	assert.True(t, (&globodns.ExportStatus{Status: "ok"}).Succeeded())
+	assert.True(t, (&globodns.ExportStatus{Status: "Success"}).Succeeded())
+	assert.False(t, (&globodns.ExportStatus{Status: "error"}).Succeeded())
+	assert.False(t, (&globodns.ExportStatus{}).Succeeded(), "an unknown status is not a success")
+}
+
+func TestClient_BindExportNow(t *testing.T) {
+	tests := map[string]struct {
+		handler       http.Handler
+		expected      *globodns.ImmediateExport
+		expectedError string
+	}{
+		"export successfully done": {
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "POST", r.Method)
+				assert.Equal(t, "/bind9/export.json", r.URL.Path)
+				assert.Equal(t, "true", r.URL.Query().Get("now"))
+
+				fmt.Fprintf(w, `{"output": "zone example.com/IN: loaded serial 2021102901\nzone example.org/IN: loaded serial 2021102902\nOK\n"}`)
+			}),
+			expected: &globodns.ImmediateExport{
+				Output: "zone example.com/IN: loaded serial 2021102901\nzone example.org/IN: loaded serial 2021102902\nOK\n",
+				Zones:  []string{"example.com", "example.org"},
+			},
+		},
+
+		"export done with errors": {
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"output": "zone example.com/IN: loaded serial 2021102901\ndns_master_load: example.org:12: unknown RR type 'AA'\nzone example.org/IN: loading from master file example.org failed: unknown class/type\nERROR: zone example.org not loaded"}`)
+			}),
+			expected: &globodns.ImmediateExport{
+				Output: "zone example.com/IN: loaded serial 2021102901\ndns_master_load: example.org:12: unknown RR type 'AA'\nzone example.org/IN: loading from master file example.org failed: unknown class/type\nERROR: zone example.org not loaded",
+				Zones:  []string{"example.com"},
+				Errors: []string{
+					"zone example.org/IN: loading from master file example.org failed: unknown class/type",
+					"ERROR: zone example.org not loaded",
+				},
+			},
+		},
+
+		"export done with warnings only": {
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"output": "example.com:5: TTL set to prior TTL (3600)\nzone example.com/IN: ns.example.com has no address records (A or AAAA)\nzone example.com/IN: loaded serial 2021102901\nOK\n"}`)
+			}),
+			expected: &globodns.ImmediateExport{
+				Output: "example.com:5: TTL set to prior TTL (3600)\nzone example.com/IN: ns.example.com has no address records (A or AAAA)\nzone example.com/IN: loaded serial 2021102901\nOK\n",
+				Zones:  []string{"example.com"},
+			},
+		},
+
+		"server returns an error": {
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprintf(w, `{"error":"NOT AUTHORIZED"}`)
+			}),
+			expectedError: `globodns: unexpected HTTP status code: Code: 403 Body: {"error":"NOT AUTHORIZED"}`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			client, err := globodns.New(nil, server.URL)
+			require.NoError(t, err)
+
+			got, err := client.Bind.ExportNow(context.TODO())
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, got.Duration > 0)
+			got.Duration = 0
+			require.NotNil(t, got.Report)
+			assert.Equal(t, len(tt.expected.Errors) > 0, got.Report.Failed())
+			got.Report = nil
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestScheduleExport_UnmarshalJSON(t *testing.T) {
+	tests := map[string]struct {
+		data          string
+		expected      globodns.ScheduleExport
+		expectedError string
+	}{
+		"date with numeric offset": {
+			data: `{"output": "BIND export scheduled", "schedule_date": "2021-10-29 17:43:00 -0300"}`,
+			expected: globodns.ScheduleExport{
+				Output:       "BIND export scheduled",
+				ScheduleDate: time.Date(2021, 10, 29, 20, 43, 0, 0, time.UTC),
+			},
+		},
+
+		"date in RFC 3339": {
+			data: `{"output": "BIND export scheduled", "schedule_date": "2021-10-29T17:43:00.000-03:00"}`,
+			expected: globodns.ScheduleExport{
+				Output:       "BIND export scheduled",
+				ScheduleDate: time.Date(2021, 10, 29, 20, 43, 0, 0, time.UTC),
+			},
+		},
+
+		"date without offset should be in local time": {
+			data: `{"schedule_date": "2021-10-29 17:43:00"}`,
+			expected: globodns.ScheduleExport{
+				ScheduleDate: time.Date(2021, 10, 29, 17, 43, 0, 0, time.Local),
+			},
+		},
+
+		"null and unknown fields": {
+			data: `{"output": null, "schedule_date": null, "job_id": 42, "queue": "bind"}`,
+			expected: globodns.ScheduleExport{
+				Extra: map[string]json.RawMessage{
+					"job_id": json.RawMessage(`42`),
+					"queue":  json.RawMessage(`"bind"`),
+				},
+			},
+		},
+
+		"unknown date layout": {
+			data:          `{"schedule_date": "29 Oct 17:43"}`,
+			expectedError: `parsing time "29 Oct 17:43" as "2006-01-02 15:04:05 -0700": cannot parse "29 Oct 17:43" as "2006"`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got globodns.ScheduleExport
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, tt.expected.ScheduleDate.Equal(got.ScheduleDate))
+			got.ScheduleDate = tt.expected.ScheduleDate
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestScheduleExport_MarshalJSON(t *testing.T) {
+	se := globodns.ScheduleExport{
+		Output:       "BIND export scheduled",
+		ScheduleDate: time.Date(2021, 10, 29, 17, 43, 0, 0, time.FixedZone("", -3*60*60)),
+		Extra:        map[string]json.RawMessage{"job_id": json.RawMessage(`42`)},
+	}
+
+	data, err := json.Marshal(se)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"output": "BIND export scheduled", "schedule_date": "2021-10-29 17:43:00 -0300", "job_id": 42}`, string(data))
+
+	var got globodns.ScheduleExport
+	err = json.Unmarshal(data, &got)
+	require.NoError(t, err)
+	assert.True(t, se.ScheduleDate.Equal(got.ScheduleDate))
+	assert.Equal(t, se.Output, got.Output)
+	assert.Equal(t, se.Extra, got.Extra)
+}
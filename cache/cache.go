@@ -0,0 +1,342 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+)
+
+const DefaultTTL = 30 * time.Second
+
+// allDomains is the pseudo domain ID used to group the entries of domain
+// listings, since they do not belong to any specific domain.
+const allDomains = -1
+
+type Options struct {
+	DomainTTL time.Duration
+	RecordTTL time.Duration
+
+	// Now is used to compute entry expiration, defaults to time.Now.
+	Now func() time.Time
+}
+
+type Cache struct {
+	Domain *DomainService
+	Record *RecordService
+
+	domainTTL time.Duration
+	recordTTL time.Duration
+	now       func() time.Time
+
+	group group
+
+	mu            sync.Mutex
+	entries       map[string]entry
+	generations   map[int]uint64
+	recordDomains map[int]int
+}
+
+type entry struct {
+	value     interface{}
+	domainID  int
+	expiresAt time.Time
+}
+
+func New(domains globodns.DomainService, records globodns.RecordService, o Options) *Cache {
+	if o.DomainTTL <= 0 {
+		o.DomainTTL = DefaultTTL
+	}
+
+	if o.RecordTTL <= 0 {
+		o.RecordTTL = DefaultTTL
+	}
+
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+
+	c := &Cache{
+		domainTTL:     o.DomainTTL,
+		recordTTL:     o.RecordTTL,
+		now:           o.Now,
+		entries:       make(map[string]entry),
+		generations:   make(map[int]uint64),
+		recordDomains: make(map[int]int),
+	}
+
+	c.Domain = &DomainService{cache: c, next: domains}
+	c.Record = &RecordService{cache: c, next: records}
+
+	return c
+}
+
+// Wrap replaces the domain and record services of c by cached ones.
+func Wrap(c *globodns.Client, o Options) *Cache {
+	cache := New(c.Domain, c.Record, o)
+	c.Domain = cache.Domain
+	c.Record = cache.Record
+	return cache
+}
+
+// Invalidate drops every cached record listing of the given domain.
+func (c *Cache) Invalidate(domainID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidate(domainID)
+}
+
+// InvalidateDomains drops every cached domain listing.
+func (c *Cache) InvalidateDomains() {
+	c.Invalidate(allDomains)
+}
+
+// Flush drops all cached entries.
+func (c *Cache) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for domainID := range c.generations {
+		c.generations[domainID]++
+	}
+
+	c.entries = make(map[string]entry)
+	c.recordDomains = make(map[int]int)
+}
+
+func (c *Cache) invalidate(domainID int) {
+	c.generations[domainID]++
+
+	for key, e := range c.entries {
+		if e.domainID == domainID {
+			delete(c.entries, key)
+		}
+	}
+
+	if domainID == allDomains {
+		return
+	}
+
+	for recordID, d := range c.recordDomains {
+		if d == domainID {
+			delete(c.recordDomains, recordID)
+		}
+	}
+}
+
+func (c *Cache) invalidateRecord(domainID, recordID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if domainID <= 0 {
+		d, ok := c.recordDomains[recordID]
+		if !ok {
+			// NOTE: we cannot tell which domain the record belongs to, so
+			// dropping every record listing is the only safe choice.
+			c.invalidateAllRecords()
+			return
+		}
+
+		domainID = d
+	}
+
+	c.invalidate(domainID)
+}
+
+func (c *Cache) invalidateAllRecords() {
+	for domainID := range c.generations {
+		if domainID != allDomains {
+			c.invalidate(domainID)
+		}
+	}
+}
+
+func (c *Cache) get(ctx context.Context, key string, domainID int, ttl time.Duration, fetch func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		if c.now().Before(e.expiresAt) {
+			c.mu.Unlock()
+			return e.value, nil
+		}
+
+		delete(c.entries, key)
+	}
+
+	gen := c.generations[domainID]
+	c.generations[domainID] = gen
+	c.mu.Unlock()
+
+	// NOTE: generation is part of the flight key so that callers arriving
+	// after an invalidation never share a request started before it.
+	return c.group.do(ctx, fmt.Sprintf("%s#%d", key, gen), func(ctx context.Context) (interface{}, error) {
+		v, err := fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if c.generations[domainID] == gen {
+			c.entries[key] = entry{value: v, domainID: domainID, expiresAt: c.now().Add(ttl)}
+		}
+
+		return v, nil
+	})
+}
+
+func (c *Cache) indexRecords(records []globodns.Record) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, r := range records {
+		if r.ID > 0 && r.DomainID > 0 {
+			c.recordDomains[r.ID] = r.DomainID
+		}
+	}
+}
+
+var _ globodns.DomainService = &DomainService{}
+
+type DomainService struct {
+	cache *Cache
+	next  globodns.DomainService
+}
+
+func (s *DomainService) Create(ctx context.Context, d globodns.Domain) (*globodns.Domain, error) {
+	defer s.cache.InvalidateDomains()
+	return s.next.Create(ctx, d)
+}
+
+func (s *DomainService) Delete(ctx context.Context, domainID int) error {
+	defer s.cache.InvalidateDomains()
+	defer s.cache.Invalidate(domainID)
+	return s.next.Delete(ctx, domainID)
+}
+
+// Get is not cached, like RecordService.Get.
+func (s *DomainService) Get(ctx context.Context, domainID int) (*globodns.Domain, error) {
+	return s.next.Get(ctx, domainID)
+}
+
+func (s *DomainService) List(ctx context.Context, p *globodns.ListDomainsParameters) ([]globodns.Domain, error) {
+	key := "domains:list:" + p.AsURLValues().Encode()
+
+	v, err := s.cache.get(ctx, key, allDomains, s.cache.domainTTL, func(ctx context.Context) (interface{}, error) {
+		return s.next.List(ctx, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return copyDomains(v.([]globodns.Domain)), nil
+}
+
+func (s *DomainService) ListPage(ctx context.Context, p *globodns.ListDomainsParameters) (*globodns.DomainPage, error) {
+	key := "domains:page:" + p.AsURLValues().Encode()
+
+	v, err := s.cache.get(ctx, key, allDomains, s.cache.domainTTL, func(ctx context.Context) (interface{}, error) {
+		return s.next.ListPage(ctx, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := *v.(*globodns.DomainPage)
+	page.Domains = copyDomains(page.Domains)
+	return &page, nil
+}
+
+func (s *DomainService) Update(ctx context.Context, d globodns.Domain) error {
+	defer s.cache.InvalidateDomains()
+	return s.next.Update(ctx, d)
+}
+
+var _ globodns.RecordService = &RecordService{}
+
+type RecordService struct {
+	cache *Cache
+	next  globodns.RecordService
+}
+
+func (s *RecordService) Create(ctx context.Context, r globodns.Record) (*globodns.Record, error) {
+	defer s.cache.invalidateRecord(r.DomainID, r.ID)
+	return s.next.Create(ctx, r)
+}
+
+func (s *RecordService) Delete(ctx context.Context, recordID int) error {
+	defer s.cache.invalidateRecord(0, recordID)
+	return s.next.Delete(ctx, recordID)
+}
+
+// Get is not cached, so callers always see the current state of a record.
+func (s *RecordService) Get(ctx context.Context, recordID int) (*globodns.Record, error) {
+	return s.next.Get(ctx, recordID)
+}
+
+func (s *RecordService) List(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) ([]globodns.Record, error) {
+	key := fmt.Sprintf("records:%d:list:%s", domainID, p.AsURLValues().Encode())
+
+	v, err := s.cache.get(ctx, key, domainID, s.cache.recordTTL, func(ctx context.Context) (interface{}, error) {
+		rs, err := s.next.List(ctx, domainID, p)
+		if err != nil {
+			return nil, err
+		}
+
+		s.cache.indexRecords(rs)
+		return rs, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return copyRecords(v.([]globodns.Record)), nil
+}
+
+func (s *RecordService) ListPage(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) (*globodns.RecordPage, error) {
+	key := fmt.Sprintf("records:%d:page:%s", domainID, p.AsURLValues().Encode())
+
+	v, err := s.cache.get(ctx, key, domainID, s.cache.recordTTL, func(ctx context.Context) (interface{}, error) {
+		page, err := s.next.ListPage(ctx, domainID, p)
+		if err != nil {
+			return nil, err
+		}
+
+		s.cache.indexRecords(page.Records)
+		return page, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := *v.(*globodns.RecordPage)
+	page.Records = copyRecords(page.Records)
+	return &page, nil
+}
+
+func (s *RecordService) Update(ctx context.Context, r globodns.Record) error {
+	defer s.cache.invalidateRecord(r.DomainID, r.ID)
+	return s.next.Update(ctx, r)
+}
+
+func copyDomains(ds []globodns.Domain) []globodns.Domain {
+	if ds == nil {
+		return nil
+	}
+
+	return append([]globodns.Domain(nil), ds...)
+}
+
+func copyRecords(rs []globodns.Record) []globodns.Record {
+	if rs == nil {
+		return nil
+	}
+
+	return append([]globodns.Record(nil), rs...)
+}
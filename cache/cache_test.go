@@ -0,0 +1,183 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/cache"
+	"github.com/tsuru/go-globodnsclient/fake"
+)
+
+func TestCache_RecordList(t *testing.T) {
+	var calls int32
+
+	client := fake.New()
+	client.Record.(*fake.FakeRecordService).FakeList = func(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) ([]globodns.Record, error) {
+		n := atomic.AddInt32(&calls, 1)
+		return []globodns.Record{{ID: 10, DomainID: domainID, Name: fmt.Sprintf("www%d", n), Type: "A"}}, nil
+	}
+	client.Record.(*fake.FakeRecordService).FakeCreate = func(ctx context.Context, r globodns.Record) (*globodns.Record, error) {
+		return &r, nil
+	}
+	client.Record.(*fake.FakeRecordService).FakeDelete = func(ctx context.Context, recordID int) error {
+		return nil
+	}
+
+	now := time.Date(2021, 10, 29, 17, 43, 0, 0, time.UTC)
+	c := cache.Wrap(client, cache.Options{RecordTTL: time.Minute, Now: func() time.Time { return now }})
+
+	list := func() string {
+		rs, err := client.Record.List(context.TODO(), 100, nil)
+		require.NoError(t, err)
+		require.Len(t, rs, 1)
+		return rs[0].Name
+	}
+
+	assert.Equal(t, "www1", list())
+	assert.Equal(t, "www1", list(), "should be served from cache")
+
+	now = now.Add(2 * time.Minute)
+	assert.Equal(t, "www2", list(), "entry should have expired")
+
+	_, err := client.Record.Create(context.TODO(), globodns.Record{DomainID: 100, Name: "mail", Type: "A"})
+	require.NoError(t, err)
+	assert.Equal(t, "www3", list(), "create should have invalidated the domain records")
+
+	err = client.Record.Delete(context.TODO(), 10)
+	require.NoError(t, err)
+	assert.Equal(t, "www4", list(), "delete should have invalidated the domain records")
+
+	c.Invalidate(200)
+	assert.Equal(t, "www4", list(), "invalidating another domain should not affect this one")
+
+	c.Invalidate(100)
+	assert.Equal(t, "www5", list())
+}
+
+func TestCache_DomainList(t *testing.T) {
+	var calls int32
+
+	release := make(chan struct{})
+
+	client := fake.New()
+	client.Domain.(*fake.FakeDomainService).FakeList = func(ctx context.Context, p *globodns.ListDomainsParameters) ([]globodns.Domain, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return []globodns.Domain{{ID: 1, Name: "example.com"}}, nil
+	}
+
+	c := cache.Wrap(client, cache.Options{})
+
+	joined := make(chan string, 10)
+	cache.SetJoinHook(c, func(key string) { joined <- key })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ds, err := client.Domain.List(context.TODO(), &globodns.ListDomainsParameters{Query: "example.com"})
+			assert.NoError(t, err)
+			assert.Equal(t, []globodns.Domain{{ID: 1, Name: "example.com"}}, ds)
+		}()
+	}
+
+	// NOTE: one goroutine starts the flight, every other one joins it.
+	for i := 0; i < 9; i++ {
+		<-joined
+	}
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "concurrent identical calls should be collapsed")
+
+	_, err := client.Domain.List(context.TODO(), &globodns.ListDomainsParameters{Query: "example.org"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "different parameters should not share entries")
+
+	c.InvalidateDomains()
+
+	_, err = client.Domain.List(context.TODO(), &globodns.ListDomainsParameters{Query: "example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestCache_CallerCancellation(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	fetchErr := make(chan error, 1)
+
+	client := fake.New()
+	client.Domain.(*fake.FakeDomainService).FakeList = func(ctx context.Context, p *globodns.ListDomainsParameters) ([]globodns.Domain, error) {
+		close(started)
+		<-release
+		fetchErr <- ctx.Err()
+		return []globodns.Domain{{ID: 1, Name: "example.com"}}, nil
+	}
+
+	c := cache.Wrap(client, cache.Options{})
+
+	joined := make(chan string, 1)
+	cache.SetJoinHook(c, func(key string) { joined <- key })
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := client.Domain.List(ctx, nil)
+		first <- err
+	}()
+
+	<-started
+
+	second := make(chan []globodns.Domain, 1)
+	go func() {
+		ds, err := client.Domain.List(context.Background(), nil)
+		assert.NoError(t, err)
+		second <- ds
+	}()
+
+	<-joined
+
+	cancel()
+	assert.ErrorIs(t, <-first, context.Canceled, "the caller giving up should return right away")
+
+	close(release)
+	assert.Equal(t, []globodns.Domain{{ID: 1, Name: "example.com"}}, <-second)
+	assert.NoError(t, <-fetchErr, "the shared fetch should not inherit the first caller cancellation")
+}
+
+func TestCache_ErrorsAreNotCached(t *testing.T) {
+	var calls int32
+
+	client := fake.New()
+	client.Domain.(*fake.FakeDomainService).FakeList = func(ctx context.Context, p *globodns.ListDomainsParameters) ([]globodns.Domain, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return nil, fmt.Errorf("some error")
+		}
+
+		return []globodns.Domain{{ID: 1}}, nil
+	}
+
+	cache.Wrap(client, cache.Options{})
+
+	_, err := client.Domain.List(context.TODO(), nil)
+	assert.EqualError(t, err, "some error")
+
+	ds, err := client.Domain.List(context.TODO(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []globodns.Domain{{ID: 1}}, ds)
+}
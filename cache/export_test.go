@@ -0,0 +1,13 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+// SetJoinHook makes f be called whenever a caller joins a fetch in progress.
+func SetJoinHook(c *Cache, f func(key string)) {
+	c.group.mu.Lock()
+	defer c.group.mu.Unlock()
+
+	c.group.joined = f
+}
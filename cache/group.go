@@ -0,0 +1,108 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type call struct {
+	done    chan struct{}
+	val     interface{}
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// group collapses concurrent calls sharing the same key into a single
+// execution, in the same fashion of golang.org/x/sync/singleflight.
+//
+// The shared execution is not bound to the context of whoever started it, so
+// that caller giving up does not fail the others; each caller waits until its
+// own context is done instead. The execution is canceled once every caller
+// has given up.
+type group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+
+	// joined, if set, is called whenever a caller joins an execution in
+	// progress.
+	joined func(key string)
+}
+
+func (g *group) do(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+
+	c, ok := g.calls[key]
+	if !ok {
+		callCtx, cancel := context.WithCancel(detached{ctx})
+
+		c = &call{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = c
+
+		go g.run(callCtx, key, c, fn)
+	}
+
+	c.waiters++
+	joined := g.joined
+	g.mu.Unlock()
+
+	if ok && joined != nil {
+		joined(key)
+	}
+
+	select {
+	case <-c.done:
+		return c.val, c.err
+
+	case <-ctx.Done():
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		c.waiters--
+		if c.waiters == 0 {
+			c.cancel()
+			g.forget(key, c)
+		}
+
+		return nil, ctx.Err()
+	}
+}
+
+func (g *group) run(ctx context.Context, key string, c *call, fn func(ctx context.Context) (interface{}, error)) {
+	defer c.cancel()
+
+	c.val, c.err = fn(ctx)
+
+	g.mu.Lock()
+	g.forget(key, c)
+	g.mu.Unlock()
+
+	close(c.done)
+}
+
+// forget removes c, unless a newer call took its place already. It must be
+// called with mu held.
+func (g *group) forget(key string, c *call) {
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+}
+
+// detached keeps the values of its parent context, e.g. the caller identity,
+// but neither its deadline nor its cancellation.
+type detached struct {
+	parent context.Context
+}
+
+func (detached) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detached) Done() <-chan struct{}               { return nil }
+func (detached) Err() error                          { return nil }
+func (d detached) Value(key interface{}) interface{} { return d.parent.Value(key) }
@@ -0,0 +1,209 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cassette records HTTP interactions with GloboDNS to files, named
+// cassettes, and replays them later so tests can run offline.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Redacted replaces secrets in recorded interactions.
+const Redacted = "REDACTED"
+
+var (
+	secretHeaders = []string{"X-Auth-Token", "Authorization", "Cookie", "Set-Cookie"}
+	secretFields  = []string{"auth_token", "authentication_token", "password"}
+)
+
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Load reads the cassette stored at path.
+func Load(path string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Cassette
+	if err = json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("cassette: could not decode %s: %w", path, err)
+	}
+
+	return &c, nil
+}
+
+// Save atomically writes the cassette to path.
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	f, err := ioutil.TempFile(dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// DefaultScrub replaces the authentication token and credentials found in
+// headers, query strings and JSON bodies with Redacted.
+func DefaultScrub(i *Interaction) {
+	scrubHeaders(i.Request.Headers)
+	scrubHeaders(i.Response.Headers)
+
+	if u, err := url.Parse(i.Request.URL); err == nil {
+		q := u.Query()
+		for _, f := range secretFields {
+			if _, ok := q[f]; ok {
+				q.Set(f, Redacted)
+			}
+		}
+
+		u.RawQuery = q.Encode()
+		i.Request.URL = u.String()
+	}
+
+	i.Request.Body = scrubBody(i.Request.Body)
+	i.Response.Body = scrubBody(i.Response.Body)
+}
+
+func scrubHeaders(h http.Header) {
+	for _, name := range secretHeaders {
+		if _, ok := h[name]; ok {
+			h.Set(name, Redacted)
+		}
+	}
+}
+
+func scrubBody(body string) string {
+	var v interface{}
+	if err := json.Unmarshal([]byte(body), &v); err != nil {
+		return body
+	}
+
+	if !scrubValue(v) {
+		return body
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+
+	return string(data)
+}
+
+func scrubValue(v interface{}) bool {
+	var changed bool
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			if isSecretField(k) {
+				v[k] = Redacted
+				changed = true
+				continue
+			}
+
+			changed = scrubValue(item) || changed
+		}
+
+	case []interface{}:
+		for _, item := range v {
+			changed = scrubValue(item) || changed
+		}
+	}
+
+	return changed
+}
+
+func isSecretField(name string) bool {
+	for _, f := range secretFields {
+		if strings.EqualFold(f, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newRequest(req *http.Request, body []byte) Request {
+	return Request{
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Headers: req.Header.Clone(),
+		Body:    string(body),
+	}
+}
+
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+
+	defer r.Body.Close()
+	return ioutil.ReadAll(r.Body)
+}
+
+func jsonEqual(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	var va, vb interface{}
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return strings.TrimSpace(a) == strings.TrimSpace(b)
+	}
+
+	ja, _ := json.Marshal(va)
+	jb, _ := json.Marshal(vb)
+	return bytes.Equal(ja, jb)
+}
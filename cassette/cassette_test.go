@@ -0,0 +1,155 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cassette_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/cassette"
+	"github.com/tsuru/go-globodnsclient/fake"
+	"github.com/tsuru/go-globodnsclient/fake/server"
+)
+
+func record(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "globodns-cassette")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	b := fake.NewBackend()
+	require.NoError(t, b.Seed(fake.State{
+		Domains: []globodns.Domain{{ID: 10, Name: "example.com", AddressingType: "N"}},
+	}))
+
+	s := server.New(b)
+	t.Cleanup(s.Close)
+	s.SetToken("s3cr3t-t0k3n")
+	s.AddUser("admin@example.com", "p4ssw0rd")
+
+	path := filepath.Join(dir, "cassette.json")
+	rec := cassette.NewRecorder(path, nil)
+
+	client, err := globodns.New(rec.Client(), s.URL)
+	require.NoError(t, err)
+
+	ctx := context.TODO()
+
+	token, err := client.SignIn(ctx, "admin@example.com", "p4ssw0rd")
+	require.NoError(t, err)
+	require.Equal(t, "s3cr3t-t0k3n", token)
+
+	_, err = client.Record.Create(ctx, globodns.Record{DomainID: 10, Name: "www", Type: "A", Content: "169.196.100.100"})
+	require.NoError(t, err)
+
+	_, err = client.Record.Create(ctx, globodns.Record{DomainID: 10, Name: "api", Type: "A", Content: "169.196.100.101"})
+	require.NoError(t, err)
+
+	_, err = client.Domain.List(ctx, &globodns.ListDomainsParameters{Query: "example"})
+	require.NoError(t, err)
+
+	return path
+}
+
+func TestRecorder(t *testing.T) {
+	path := record(t)
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "s3cr3t-t0k3n")
+	assert.NotContains(t, string(data), "p4ssw0rd")
+
+	c, err := cassette.Load(path)
+	require.NoError(t, err)
+	require.Len(t, c.Interactions, 5)
+
+	assert.Equal(t, "POST", c.Interactions[0].Request.Method)
+	assert.Equal(t, `{"user":{"email":"admin@example.com","password":"REDACTED"}}`, c.Interactions[0].Request.Body)
+	assert.JSONEq(t, `{"authentication_token":"REDACTED","email":"admin@example.com"}`, c.Interactions[0].Response.Body)
+	assert.Equal(t, cassette.Redacted, c.Interactions[1].Request.Headers.Get("X-Auth-Token"))
+	assert.Equal(t, 201, c.Interactions[1].Response.StatusCode)
+}
+
+func TestReplayer(t *testing.T) {
+	path := record(t)
+	ctx := context.TODO()
+
+	t.Run("replays recorded interactions", func(t *testing.T) {
+		r, err := cassette.Open(path)
+		require.NoError(t, err)
+
+		client, err := globodns.New(r.Client(), "http://globodns.invalid")
+		require.NoError(t, err)
+		client.SetToken("other-token")
+
+		domains, err := client.Domain.List(ctx, &globodns.ListDomainsParameters{Query: "example"})
+		require.NoError(t, err)
+		assert.Equal(t, []globodns.Domain{{ID: 10, Name: "example.com", AddressingType: "N"}}, domains)
+
+		created, err := client.Record.Create(ctx, globodns.Record{DomainID: 10, Name: "www", Type: "A", Content: "169.196.100.100"})
+		require.NoError(t, err)
+		assert.Equal(t, "www", created.Name)
+
+		created, err = client.Record.Create(ctx, globodns.Record{DomainID: 10, Name: "api", Type: "A", Content: "169.196.100.101"})
+		require.NoError(t, err)
+		assert.Equal(t, "api", created.Name)
+
+		assert.Len(t, r.Unused(), 1)
+
+		// Non-strict: used up interactions are replayed again and unknown
+		// requests are answered with 404.
+		created, err = client.Record.Create(ctx, globodns.Record{DomainID: 10, Name: "www", Type: "A", Content: "169.196.100.100"})
+		require.NoError(t, err)
+		assert.Equal(t, "api", created.Name)
+
+		_, err = client.Record.Get(ctx, 1)
+		assert.EqualError(t, err, `globodns: unexpected HTTP status code: Code: 404 Body: {"error":"NOT FOUND"}`)
+	})
+
+	t.Run("matching on body", func(t *testing.T) {
+		r, err := cassette.Open(path)
+		require.NoError(t, err)
+		r.Match |= cassette.MatchBody
+
+		client, err := globodns.New(r.Client(), "http://globodns.invalid")
+		require.NoError(t, err)
+
+		created, err := client.Record.Create(ctx, globodns.Record{DomainID: 10, Name: "api", Type: "A", Content: "169.196.100.101"})
+		require.NoError(t, err)
+		assert.Equal(t, "api", created.Name)
+
+		token, err := client.SignIn(ctx, "admin@example.com", "any password")
+		require.NoError(t, err)
+		assert.Equal(t, cassette.Redacted, token)
+	})
+
+	t.Run("strict mode", func(t *testing.T) {
+		r, err := cassette.Open(path)
+		require.NoError(t, err)
+		r.Strict = true
+
+		client, err := globodns.New(r.Client(), "http://globodns.invalid")
+		require.NoError(t, err)
+
+		_, err = client.Domain.List(ctx, &globodns.ListDomainsParameters{Query: "example"})
+		require.NoError(t, err)
+
+		_, err = client.Domain.List(ctx, &globodns.ListDomainsParameters{Query: "example"})
+		var unmatched *cassette.UnmatchedError
+		require.True(t, errors.As(err, &unmatched))
+		assert.Equal(t, "GET", unmatched.Method)
+		assert.EqualError(t, unmatched, "cassette: no recorded interaction matches GET http://globodns.invalid/domains?page=1&query=example")
+
+		_, err = client.Domain.List(ctx, &globodns.ListDomainsParameters{Query: "other"})
+		assert.True(t, errors.As(err, &unmatched))
+	})
+}
@@ -0,0 +1,95 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cassette
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+var _ http.RoundTripper = &Recorder{}
+
+// Recorder is a RoundTripper that sends requests through the next transport
+// and appends every interaction to a cassette file. Secrets are scrubbed
+// before writing, the caller still gets the original response.
+type Recorder struct {
+	// Scrub removes secrets from interactions, defaults to DefaultScrub.
+	Scrub func(*Interaction)
+
+	path string
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder returns a recorder writing to the cassette at path, replacing
+// any existing one. A nil next means http.DefaultTransport.
+func NewRecorder(path string, next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &Recorder{path: path, next: next}
+}
+
+// Client returns an HTTP client using r as transport, to be given to
+// globodns.New.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	out := req.Clone(req.Context())
+	if body != nil {
+		out.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	res, err := r.next.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+
+	i := Interaction{
+		Request: newRequest(req, body),
+		Response: Response{
+			StatusCode: res.StatusCode,
+			Headers:    res.Header.Clone(),
+			Body:       string(resBody),
+		},
+	}
+
+	scrub := r.Scrub
+	if scrub == nil {
+		scrub = DefaultScrub
+	}
+
+	scrub(&i)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, i)
+	if err = r.cassette.Save(r.path); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
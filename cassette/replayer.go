@@ -0,0 +1,201 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cassette
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+)
+
+// Match selects which parts of a request must be equal to the recorded one.
+type Match uint
+
+const (
+	MatchMethod Match = 1 << iota
+	MatchPath
+	MatchQuery
+	// MatchBody compares JSON bodies semantically, other bodies byte by byte.
+	MatchBody
+
+	DefaultMatch = MatchMethod | MatchPath | MatchQuery
+)
+
+// UnmatchedError is returned in strict mode for requests matching no
+// interaction left in the cassette.
+type UnmatchedError struct {
+	Method string
+	URL    string
+}
+
+func (e *UnmatchedError) Error() string {
+	return fmt.Sprintf("cassette: no recorded interaction matches %s %s", e.Method, e.URL)
+}
+
+var _ http.RoundTripper = &Replayer{}
+
+// Replayer is a RoundTripper answering requests with the interactions of a
+// cassette, without reaching the network. The host of the URL is ignored, so
+// cassettes recorded against an environment can be replayed against any URL.
+//
+// Interactions are replayed in the order they were recorded. In strict mode
+// each one is replayed at most once and unmatched requests fail with
+// *UnmatchedError; otherwise the last matching interaction is replayed again
+// once they're used up and unmatched requests get a 404 response.
+type Replayer struct {
+	Match  Match
+	Strict bool
+	// Scrub is applied to incoming requests before matching, so that they
+	// compare equal to scrubbed recordings. Defaults to DefaultScrub.
+	Scrub func(*Interaction)
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer returns a non-strict replayer of c using DefaultMatch.
+func NewReplayer(c *Cassette) *Replayer {
+	return &Replayer{
+		Match:        DefaultMatch,
+		interactions: c.Interactions,
+		used:         make([]bool, len(c.Interactions)),
+	}
+}
+
+// Open loads the cassette at path and returns a replayer for it.
+func Open(path string) (*Replayer, error) {
+	c, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewReplayer(c), nil
+}
+
+// Client returns an HTTP client using r as transport, to be given to
+// globodns.New.
+func (r *Replayer) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Unused returns the interactions not replayed yet.
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var unused []Interaction
+	for i, used := range r.used {
+		if !used {
+			unused = append(unused, r.interactions[i])
+		}
+	}
+
+	return unused
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	in := Interaction{Request: newRequest(req, body)}
+
+	scrub := r.Scrub
+	if scrub == nil {
+		scrub = DefaultScrub
+	}
+
+	scrub(&in)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	last := -1
+	for i, recorded := range r.interactions {
+		if !r.match(in.Request, recorded.Request) {
+			continue
+		}
+
+		if !r.used[i] {
+			r.used[i] = true
+			return newResponse(req, recorded.Response), nil
+		}
+
+		last = i
+	}
+
+	if r.Strict {
+		return nil, &UnmatchedError{Method: req.Method, URL: req.URL.String()}
+	}
+
+	if last >= 0 {
+		return newResponse(req, r.interactions[last].Response), nil
+	}
+
+	return newResponse(req, Response{StatusCode: http.StatusNotFound, Body: `{"error":"NOT FOUND"}`}), nil
+}
+
+func (r *Replayer) match(in, recorded Request) bool {
+	if r.Match&MatchMethod != 0 && in.Method != recorded.Method {
+		return false
+	}
+
+	inURL, err := url.Parse(in.URL)
+	if err != nil {
+		return false
+	}
+
+	recordedURL, err := url.Parse(recorded.URL)
+	if err != nil {
+		return false
+	}
+
+	if r.Match&MatchPath != 0 && inURL.Path != recordedURL.Path {
+		return false
+	}
+
+	if r.Match&MatchQuery != 0 && !queryEqual(inURL.Query(), recordedURL.Query()) {
+		return false
+	}
+
+	if r.Match&MatchBody != 0 && !jsonEqual(in.Body, recorded.Body) {
+		return false
+	}
+
+	return true
+}
+
+func queryEqual(a, b url.Values) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+func newResponse(req *http.Request, r Response) *http.Response {
+	header := r.Headers.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader([]byte(r.Body))),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
@@ -5,12 +5,17 @@
 package globodns
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
+	"time"
 )
 
 type Client struct {
@@ -21,6 +26,9 @@ type Client struct {
 	token     string
 	userAgent string
 
+	exportPollInterval time.Duration
+	policy             Policy
+
 	Bind   BindService
 	Domain DomainService
 	Record RecordService
@@ -52,12 +60,51 @@ func (c *Client) SetUserAgent(ua string) {
 	c.userAgent = ua
 }
 
+func (c *Client) SetExportPollInterval(d time.Duration) {
+	c.exportPollInterval = d
+}
+
 func (c *Client) SetToken(token string) {
 	c.Lock()
 	defer c.Unlock()
 	c.token = token
 }
 
+// SignIn exchanges the user credentials for an authentication token, which is
+// used by the next requests.
+func (c *Client) SignIn(ctx context.Context, email, password string) (string, error) {
+	if email == "" || password == "" {
+		return "", fmt.Errorf("globodns: email and password cannot be empty")
+	}
+
+	var body bytes.Buffer
+
+	data := map[string]map[string]string{"user": {"email": email, "password": password}}
+	if err := json.NewEncoder(&body).Encode(&data); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.makeURL("/users/sign_in.json"), &body)
+	if err != nil {
+		return "", err
+	}
+
+	var got struct {
+		Token string `json:"authentication_token"`
+	}
+
+	if _, err = c.Do(req, &got); err != nil {
+		return "", err
+	}
+
+	if got.Token == "" {
+		return "", fmt.Errorf("globodns: authentication token not found in the response")
+	}
+
+	c.SetToken(got.Token)
+	return got.Token, nil
+}
+
 func (c *Client) Do(req *http.Request, out interface{}) (*http.Response, error) {
 	if req == nil {
 		return nil, fmt.Errorf("globodns: HTTP request cannot be nil")
@@ -120,5 +167,36 @@ func newUnexpectedHTTPError(res *http.Response) error {
 		return fmt.Errorf("globodns: could not read the body message")
 	}
 
-	return fmt.Errorf("globodns: unexpected HTTP status code: Code: %d Body: %s", res.StatusCode, body)
+	return &HTTPError{StatusCode: res.StatusCode, Body: body}
+}
+
+type HTTPError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("globodns: unexpected HTTP status code: Code: %d Body: %s", e.StatusCode, e.Body)
+}
+
+// IsUnavailable reports whether err means that GloboDNS could not be reached
+// or is temporarily unable to handle requests, as opposed to an error caused
+// by the request itself.
+func IsUnavailable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+
+		return false
+	}
+
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
 }
@@ -0,0 +1,86 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package globodns_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+)
+
+func TestClient_SignIn(t *testing.T) {
+	tests := map[string]struct {
+		handler       http.HandlerFunc
+		email         string
+		password      string
+		expectedError string
+	}{
+		"missing credentials": {
+			email:         "admin@example.com",
+			expectedError: "globodns: email and password cannot be empty",
+		},
+
+		"invalid credentials": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprintf(w, `{"error": "Invalid email or password."}`)
+			},
+			email:         "admin@example.com",
+			password:      "wrong",
+			expectedError: `globodns: unexpected HTTP status code: Code: 401 Body: {"error": "Invalid email or password."}`,
+		},
+
+		"signing in": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/users/sign_in.json" {
+					assert.Equal(t, "some-token", r.Header.Get("X-Auth-Token"))
+					fmt.Fprintf(w, `[]`)
+					return
+				}
+
+				assert.Equal(t, "POST", r.Method)
+				assert.Empty(t, r.Header.Get("X-Auth-Token"))
+
+				var body map[string]map[string]string
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				assert.Equal(t, map[string]string{"email": "admin@example.com", "password": "secret"}, body["user"])
+
+				fmt.Fprintf(w, `{"id": 1, "email": "admin@example.com", "authentication_token": "some-token"}`)
+			},
+			email:    "admin@example.com",
+			password: "secret",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			client, err := globodns.New(nil, server.URL)
+			require.NoError(t, err)
+
+			token, err := client.SignIn(context.TODO(), tt.email, tt.password)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "some-token", token)
+
+			_, err = client.Domain.List(context.TODO(), &globodns.ListDomainsParameters{Page: 1})
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+func exportBind(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("bind export", "")
+	now := fs.Bool("now", false, "export right away instead of scheduling")
+	wait := fs.Bool("wait", false, "wait for the scheduled export to finish")
+
+	if err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	if *now {
+		ie, err := a.client.Bind.ExportNow(ctx)
+		if err != nil {
+			return err
+		}
+
+		return a.print(ie, func(w io.Writer) {
+			fmt.Fprintln(w, strings.TrimSpace(ie.Output))
+			if len(ie.Zones) > 0 {
+				fmt.Fprintf(w, "Zones:\t%s\n", strings.Join(ie.Zones, ", "))
+			}
+		})
+	}
+
+	se, err := a.client.Bind.Export(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !*wait {
+		return a.print(se, func(w io.Writer) {
+			fmt.Fprintf(w, "Export scheduled for %s.\n", se.ScheduleDate.Format(time.RFC3339))
+		})
+	}
+
+	es, err := a.client.Bind.Wait(ctx, se)
+	if err != nil {
+		return err
+	}
+
+	return a.print(es, func(w io.Writer) {
+		fmt.Fprintf(w, "Status:\t%s\n", es.Status)
+		if es.LastExport != nil {
+			fmt.Fprintf(w, "Last export:\t%s\n", es.LastExport.Format(time.RFC3339))
+		}
+
+		if out := strings.TrimSpace(es.Output); out != "" {
+			fmt.Fprintln(w, out)
+		}
+	})
+}
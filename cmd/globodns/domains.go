@@ -0,0 +1,129 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+)
+
+func listDomains(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("domains list", "")
+	query := fs.String("query", "", "only domains matching the query")
+	view := fs.String("view", a.profile.View, "only domains of the view")
+	reverse := fs.Bool("reverse", false, "list reverse domains instead")
+
+	if err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	p := &globodns.ListDomainsParameters{Query: *query, View: *view}
+	if *reverse {
+		p.Reverse = globodns.BoolPointer(true)
+	}
+
+	domains, err := a.client.Domain.List(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	return a.printDomains(domains)
+}
+
+func getDomain(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("domains get", "<domain>")
+	if err := parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	d, err := findDomain(ctx, a.client, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	return a.printDomain(d)
+}
+
+func createDomain(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("domains create", "<name>")
+	ttl := fs.String("ttl", "", "default TTL of the records")
+	authority := fs.String("authority-type", "M", "authority type: M (master), S (slave) or F (forward)")
+	addressing := fs.String("addressing-type", "N", "addressing type: N (normal) or R (reverse)")
+	viewID := fs.Int("view-id", 0, "ID of the view")
+	notes := fs.String("notes", "", "notes about the domain")
+
+	if err := parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	d := globodns.Domain{
+		Name:           fs.Arg(0),
+		AuthorityType:  *authority,
+		AddressingType: *addressing,
+		ViewID:         *viewID,
+	}
+
+	if *ttl != "" {
+		d.TTL = ttl
+	}
+
+	if *notes != "" {
+		d.Notes = notes
+	}
+
+	created, err := a.client.Domain.Create(ctx, d)
+	if err != nil {
+		return err
+	}
+
+	return a.printDomain(created)
+}
+
+func deleteDomain(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("domains delete", "<domain>")
+	if err := parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	d, err := findDomain(ctx, a.client, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if err = a.client.Domain.Delete(ctx, d.ID); err != nil {
+		return err
+	}
+
+	return a.print(d, func(w io.Writer) {
+		fmt.Fprintf(w, "Domain %s (%d) deleted.\n", d.Name, d.ID)
+	})
+}
+
+// findDomain gets a domain by ID, or looks it up by name.
+func findDomain(ctx context.Context, c *globodns.Client, ref string) (*globodns.Domain, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		return c.Domain.Get(ctx, id)
+	}
+
+	name := strings.TrimSuffix(ref, ".")
+
+	domains, err := c.Domain.List(ctx, &globodns.ListDomainsParameters{Query: name})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range domains {
+		if strings.EqualFold(strings.TrimSuffix(d.Name, "."), name) {
+			return &d, nil
+		}
+	}
+
+	return nil, fmt.Errorf("domain %s not found", ref)
+}
@@ -0,0 +1,198 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command globodns manages domains and records of GloboDNS from the command
+// line.
+//
+// Settings are read from a profile of the config file, see package config,
+// and the -url and -token flags take precedence over them.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/config"
+)
+
+const usage = `Usage: globodns [flags] <command> [command flags] [args]
+
+Commands:
+  domains list                          list domains
+  domains get <domain>                  show a domain, by ID or name
+  domains create <name>                 create a domain
+  domains delete <domain>               delete a domain
+  records list <domain>                 list the records of a domain
+  records create <domain> <name> <type> <content>
+                                        create a record
+  records update <record-id>            update some fields of a record
+  records delete <record-id>            delete a record
+  records ensure <domain> <name> <type> <content>...
+                                        make the records with the given name
+                                        and type have exactly these contents
+  bind export                           export the zones to BIND
+
+Flags:
+`
+
+// errUsage means the command line is invalid.
+var errUsage = errors.New("invalid usage")
+
+type command func(ctx context.Context, app *app, args []string) error
+
+var commands = map[string]map[string]command{
+	"domains": {
+		"list":   listDomains,
+		"get":    getDomain,
+		"create": createDomain,
+		"delete": deleteDomain,
+	},
+	"records": {
+		"list":   listRecords,
+		"create": createRecord,
+		"update": updateRecord,
+		"delete": deleteRecord,
+		"ensure": ensureRecords,
+	},
+	"bind": {
+		"export": exportBind,
+	},
+}
+
+type app struct {
+	stdout io.Writer
+	stderr io.Writer
+
+	client  *globodns.Client
+	profile *config.Profile
+	output  string
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Getenv, os.Stdout, os.Stderr))
+}
+
+func run(args []string, getenv func(string) string, stdout, stderr io.Writer) int {
+	a := &app{stdout: stdout, stderr: stderr}
+
+	fs := flag.NewFlagSet("globodns", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+
+	url := fs.String("url", "", "GloboDNS URL")
+	token := fs.String("token", "", "GloboDNS authentication token")
+	configPath := fs.String("config", "", "config file (default $XDG_CONFIG_HOME/globodns/config.yaml)")
+	profile := fs.String("profile", "", "profile of the config file")
+	timeout := fs.Duration("timeout", 30*time.Second, "timeout of the whole command")
+	fs.StringVar(&a.output, "output", "table", "output format: table, json or yaml")
+	fs.StringVar(&a.output, "o", "table", "shorthand for -output")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if !isFormat(a.output) {
+		fmt.Fprintf(stderr, "globodns: unknown output format %q\n", a.output)
+		return 2
+	}
+
+	cmd, cmdArgs, err := findCommand(fs.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "globodns: %s\n\n", err)
+		fs.Usage()
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	loader := &config.Loader{Path: *configPath, Getenv: getenv}
+	if a.profile, err = loader.Load(*profile); err != nil {
+		fmt.Fprintf(stderr, "globodns: %s\n", err)
+		return 1
+	}
+
+	if *url != "" {
+		a.profile.URL = *url
+	}
+
+	if *token != "" {
+		a.profile.SetToken(*token)
+	}
+
+	if a.profile.UserAgent == "" {
+		a.profile.UserAgent = "globodns-cli"
+	}
+
+	if a.client, err = a.profile.Client(ctx); err != nil {
+		fmt.Fprintf(stderr, "globodns: %s\n", strings.TrimPrefix(err.Error(), "globodns: "))
+		return 1
+	}
+
+	if err = cmd(ctx, a, cmdArgs); err != nil {
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+
+		fmt.Fprintf(stderr, "globodns: %s\n", strings.TrimPrefix(err.Error(), "globodns: "))
+		return 1
+	}
+
+	return 0
+}
+
+func findCommand(args []string) (command, []string, error) {
+	if len(args) < 2 {
+		return nil, nil, fmt.Errorf("missing command")
+	}
+
+	group, found := commands[args[0]]
+	if !found {
+		return nil, nil, fmt.Errorf("unknown command %q", args[0])
+	}
+
+	cmd, found := group[args[1]]
+	if !found {
+		return nil, nil, fmt.Errorf("unknown command %q", args[0]+" "+args[1])
+	}
+
+	return cmd, args[2:], nil
+}
+
+// flagSet returns the flag set of a command whose usage lists its arguments.
+func (a *app) flagSet(name, arguments string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: globodns %s [flags] %s\n", name, arguments)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// parse parses the command flags, checking the number of arguments left.
+func parse(fs *flag.FlagSet, args []string, min, max int) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if n := fs.NArg(); n < min || (max >= 0 && n > max) {
+		fmt.Fprintf(fs.Output(), "globodns: wrong number of arguments\n")
+		fs.Usage()
+		return errUsage
+	}
+
+	return nil
+}
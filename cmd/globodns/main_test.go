@@ -0,0 +1,196 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/fake"
+	"github.com/tsuru/go-globodnsclient/fake/server"
+)
+
+func newServer(t *testing.T) (*server.Server, *fake.Backend) {
+	b := fake.NewBackend()
+	b.Now = func() time.Time { return time.Date(2021, 10, 29, 17, 43, 0, 0, time.UTC) }
+	require.NoError(t, b.Seed(fake.State{
+		Domains: []globodns.Domain{{ID: 10, Name: "example.com", AddressingType: "N", TTL: globodns.StringPointer("3600")}},
+		Records: []globodns.Record{
+			{ID: 1, DomainID: 10, Name: "www", Type: "A", Content: "169.196.100.100"},
+			{ID: 2, DomainID: 10, Name: "www", Type: "A", Content: "169.196.100.101"},
+		},
+	}))
+
+	s := server.New(b)
+	t.Cleanup(s.Close)
+	return s, b
+}
+
+func env(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func execute(s *server.Server, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, env(map[string]string{"GLOBODNS_URL": s.URL, "GLOBODNS_TOKEN": s.Token(), "HOME": "/nonexistent"}), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	s, b := newServer(t)
+
+	tests := []struct {
+		args           []string
+		expectedCode   int
+		expectedStdout string
+		expectedStderr string
+	}{
+		{
+			args:           []string{"domains", "list"},
+			expectedStdout: "ID  NAME         ADDRESSING  VIEW  TTL\n10  example.com  N                 3600\n",
+		},
+		{
+			args:           []string{"domains", "create", "-ttl", "300", "example.org"},
+			expectedStdout: "ID:               11\nName:             example.org\nAuthority type:   M\nAddressing type:  N\nView:             \nTTL:              300\nNotes:            \n",
+		},
+		{
+			args:           []string{"-o", "json", "domains", "get", "example.org"},
+			expectedStdout: "{\n  \"name\": \"example.org\",\n  \"authority_type\": \"M\",\n  \"addressing_type\": \"N\",\n  \"notes\": null,\n  \"ttl\": \"300\",\n  \"id\": 11,\n  \"view_id\": 0\n}\n",
+		},
+		{
+			args:           []string{"domains", "delete", "11"},
+			expectedStdout: "Domain example.org (11) deleted.\n",
+		},
+		{
+			args:           []string{"records", "create", "-prio", "10", "example.com", "@", "mx", "mail.example.com."},
+			expectedStdout: "ID  NAME  TYPE  TTL  PRIO  CONTENT\n3   @     MX         10    mail.example.com.\n",
+		},
+		{
+			args:           []string{"records", "update", "-content", "mx.example.com.", "3"},
+			expectedStdout: "ID  NAME  TYPE  TTL  PRIO  CONTENT\n3   @     MX         10    mx.example.com.\n",
+		},
+		{
+			args:           []string{"-output", "yaml", "records", "list", "-type", "MX", "example.com"},
+			expectedStdout: "- content: mx.example.com.\n  created_at: \"2021-10-29T17:43:00Z\"\n  domain_id: 10\n  id: 3\n  name: '@'\n  prio: 10\n  type: MX\n  updated_at: \"2021-10-29T17:43:00Z\"\n",
+		},
+		{
+			args:           []string{"records", "delete", "3"},
+			expectedStdout: "Record MX @ (3) deleted.\n",
+		},
+		{
+			args:           []string{"records", "ensure", "-dry-run", "example.com", "www", "A", "169.196.100.100"},
+			expectedStderr: "- www A 169.196.100.101\nPlan: 0 to create, 0 to update, 1 to delete.\n",
+		},
+		{
+			args:           []string{"records", "ensure", "10", "www", "A", "169.196.100.100", "169.196.100.102"},
+			expectedStdout: "ID  NAME  TYPE  TTL  PRIO  CONTENT\n1   www   A                169.196.100.100\n2   www   A                169.196.100.102\n",
+			expectedStderr: "~ www A 169.196.100.101 -> 169.196.100.102\nPlan: 0 to create, 1 to update, 0 to delete.\n",
+		},
+		{
+			args:           []string{"records", "ensure", "10", "www", "A", "169.196.100.100", "169.196.100.102"},
+			expectedStderr: "No changes.\n",
+		},
+		{
+			args:           []string{"bind", "export", "-now"},
+			expectedStdout: "BIND export finished\n",
+		},
+		{
+			args:           []string{"records", "delete", "666"},
+			expectedCode:   1,
+			expectedStderr: "globodns: unexpected HTTP status code: Code: 404 Body: {\"error\":\"NOT FOUND\"}\n",
+		},
+		{
+			args:           []string{"domains", "get", "example.net"},
+			expectedCode:   1,
+			expectedStderr: "globodns: domain example.net not found\n",
+		},
+		{
+			args:           []string{"-o", "xml", "domains", "list"},
+			expectedCode:   2,
+			expectedStderr: "globodns: unknown output format \"xml\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			code, stdout, stderr := execute(s, tt.args...)
+			assert.Equal(t, tt.expectedCode, code)
+			assert.Equal(t, tt.expectedStdout, stdout)
+			assert.Equal(t, tt.expectedStderr, stderr)
+		})
+	}
+
+	assert.Len(t, b.Snapshot().Domains, 1)
+}
+
+func TestRun_Usage(t *testing.T) {
+	s, _ := newServer(t)
+
+	code, _, stderr := execute(s, "records", "unknown")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "globodns: unknown command \"records unknown\"\n\nUsage: globodns [flags] <command>")
+
+	code, _, stderr = execute(s, "records", "create", "example.com", "www")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "globodns: wrong number of arguments\nUsage: globodns records create [flags] <domain> <name> <type> <content>\n")
+}
+
+func TestRun_Config(t *testing.T) {
+	s, _ := newServer(t)
+
+	dir, err := ioutil.TempDir("", "globodns-cli")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "globodns", "config.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, ioutil.WriteFile(path, []byte(`current_profile: staging
+profiles:
+  staging:
+    url: `+s.URL+`
+    token: wrong
+  lab:
+    url: `+s.URL+`
+    token: `+s.Token()+`
+    view: lab
+`), 0600))
+
+	vars := env(map[string]string{"XDG_CONFIG_HOME": dir})
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"domains", "list"}, vars, &stdout, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "Code: 401")
+
+	stdout.Reset()
+	stderr.Reset()
+	code = run([]string{"-token", s.Token(), "domains", "list"}, vars, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "example.com")
+
+	stdout.Reset()
+	code = run([]string{"-profile", "lab", "domains", "list"}, vars, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Equal(t, "ID  NAME  ADDRESSING  VIEW  TTL\n", stdout.String())
+
+	stderr.Reset()
+	code = run([]string{"-profile", "production", "domains", "list"}, vars, &stdout, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "globodns: config: profile \"production\" not found\n", stderr.String())
+
+	stderr.Reset()
+	code = run([]string{"domains", "list"}, env(map[string]string{"HOME": dir}), &stdout, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "globodns: config: URL of profile \"default\" is not set\n", stderr.String())
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+)
+
+func isFormat(s string) bool {
+	switch s {
+	case "table", "json", "yaml":
+		return true
+	}
+
+	return false
+}
+
+// print writes v in the output format, using table to write it as a table.
+func (a *app) print(v interface{}, table func(w io.Writer)) error {
+	switch a.output {
+	case "json":
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+
+	case "yaml":
+		// Going through JSON keeps the field names used by GloboDNS.
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		var generic interface{}
+		if err = json.Unmarshal(data, &generic); err != nil {
+			return err
+		}
+
+		enc := yaml.NewEncoder(a.stdout)
+		if err = enc.Encode(generic); err != nil {
+			return err
+		}
+
+		return enc.Close()
+	}
+
+	tw := tabwriter.NewWriter(a.stdout, 0, 8, 2, ' ', 0)
+	table(tw)
+	return tw.Flush()
+}
+
+func (a *app) printDomains(domains []globodns.Domain) error {
+	return a.print(domains, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tNAME\tADDRESSING\tVIEW\tTTL")
+		for _, d := range domains {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", d.ID, d.Name, d.AddressingType, optionalInt(d.ViewID), globodns.StringValue(d.TTL))
+		}
+	})
+}
+
+func (a *app) printDomain(d *globodns.Domain) error {
+	return a.print(d, func(w io.Writer) {
+		fmt.Fprintf(w, "ID:\t%d\n", d.ID)
+		fmt.Fprintf(w, "Name:\t%s\n", d.Name)
+		fmt.Fprintf(w, "Authority type:\t%s\n", d.AuthorityType)
+		fmt.Fprintf(w, "Addressing type:\t%s\n", d.AddressingType)
+		fmt.Fprintf(w, "View:\t%s\n", optionalInt(d.ViewID))
+		fmt.Fprintf(w, "TTL:\t%s\n", globodns.StringValue(d.TTL))
+		fmt.Fprintf(w, "Notes:\t%s\n", globodns.StringValue(d.Notes))
+	})
+}
+
+func (a *app) printRecords(records []globodns.Record) error {
+	return a.print(records, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tNAME\tTYPE\tTTL\tPRIO\tCONTENT")
+		for _, r := range records {
+			prio := ""
+			if r.Prio != nil {
+				prio = strconv.Itoa(*r.Prio)
+			}
+
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", r.ID, r.Name, r.Type, globodns.StringValue(r.TTL), prio, r.Content)
+		}
+	})
+}
+
+func (a *app) printRecord(r *globodns.Record) error {
+	return a.printRecords([]globodns.Record{*r})
+}
+
+func optionalInt(n int) string {
+	if n == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(n)
+}
@@ -0,0 +1,255 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/reconcile"
+)
+
+// intFlag is an integer flag which is nil unless set.
+type intFlag struct {
+	value *int
+}
+
+var _ flag.Value = &intFlag{}
+
+func (f *intFlag) String() string {
+	if f.value == nil {
+		return ""
+	}
+
+	return strconv.Itoa(*f.value)
+}
+
+func (f *intFlag) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+
+	f.value = &n
+	return nil
+}
+
+func listRecords(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("records list", "<domain>")
+	query := fs.String("query", "", "only records matching the query")
+	rtype := fs.String("type", "", "only records of the type")
+
+	if err := parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	d, err := findDomain(ctx, a.client, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	records, err := a.client.Record.List(ctx, d.ID, &globodns.ListRecordsParameters{Query: *query})
+	if err != nil {
+		return err
+	}
+
+	if *rtype != "" {
+		records = filterRecords(records, "", *rtype)
+	}
+
+	return a.printRecords(records)
+}
+
+func createRecord(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("records create", "<domain> <name> <type> <content>")
+	ttl := fs.String("ttl", "", "TTL of the record, the domain one if empty")
+	var prio intFlag
+	fs.Var(&prio, "prio", "priority of MX and SRV records")
+
+	if err := parse(fs, args, 4, 4); err != nil {
+		return err
+	}
+
+	d, err := findDomain(ctx, a.client, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	r := globodns.Record{
+		DomainID: d.ID,
+		Name:     fs.Arg(1),
+		Type:     strings.ToUpper(fs.Arg(2)),
+		Content:  fs.Arg(3),
+		Prio:     prio.value,
+	}
+
+	if *ttl != "" {
+		r.TTL = ttl
+	}
+
+	created, err := a.client.Record.Create(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	return a.printRecord(created)
+}
+
+func updateRecord(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("records update", "<record-id>")
+	name := fs.String("name", "", "new name")
+	rtype := fs.String("type", "", "new type")
+	content := fs.String("content", "", "new content")
+	ttl := fs.String("ttl", "", "new TTL")
+	var prio intFlag
+	fs.Var(&prio, "prio", "new priority")
+
+	if err := parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	id, err := recordID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	r, err := a.client.Record.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "name":
+			r.Name = *name
+		case "type":
+			r.Type = strings.ToUpper(*rtype)
+		case "content":
+			r.Content = *content
+		case "ttl":
+			r.TTL = ttl
+		case "prio":
+			r.Prio = prio.value
+		}
+	})
+
+	if err = a.client.Record.Update(ctx, *r); err != nil {
+		return err
+	}
+
+	return a.printRecord(r)
+}
+
+func deleteRecord(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("records delete", "<record-id>")
+	if err := parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	id, err := recordID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	r, err := a.client.Record.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = a.client.Record.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	return a.print(r, func(w io.Writer) {
+		fmt.Fprintf(w, "Record %s %s (%d) deleted.\n", r.Type, r.Name, r.ID)
+	})
+}
+
+func ensureRecords(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("records ensure", "<domain> <name> <type> <content>...")
+	ttl := fs.String("ttl", "", "TTL of the records, any if empty")
+	var prio intFlag
+	fs.Var(&prio, "prio", "priority of MX and SRV records, any if unset")
+	dryRun := fs.Bool("dry-run", false, "only show the changes")
+	force := fs.Bool("force", false, "change protected records, e.g. the apex NS and MX")
+
+	if err := parse(fs, args, 4, -1); err != nil {
+		return err
+	}
+
+	d, err := findDomain(ctx, a.client, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	name, rtype := fs.Arg(1), strings.ToUpper(fs.Arg(2))
+
+	var desired []globodns.Record
+	for _, content := range fs.Args()[3:] {
+		r := globodns.Record{DomainID: d.ID, Name: name, Type: rtype, Content: content, Prio: prio.value}
+		if *ttl != "" {
+			r.TTL = ttl
+		}
+
+		desired = append(desired, r)
+	}
+
+	current, err := a.client.Record.List(ctx, d.ID, &globodns.ListRecordsParameters{Query: name})
+	if err != nil {
+		return err
+	}
+
+	plan := reconcile.Diff(d.ID, filterRecords(current, name, rtype), desired, []string{rtype})
+	fmt.Fprint(a.stderr, plan.String())
+
+	if *dryRun || plan.Empty() {
+		return nil
+	}
+
+	r := &reconcile.Reconciler{Records: a.client.Record, Override: *force}
+	if _, err = r.Apply(ctx, plan); err != nil {
+		return err
+	}
+
+	records, err := a.client.Record.List(ctx, d.ID, &globodns.ListRecordsParameters{Query: name})
+	if err != nil {
+		return err
+	}
+
+	return a.printRecords(filterRecords(records, name, rtype))
+}
+
+// filterRecords returns the records with the given name and type, any if
+// empty.
+func filterRecords(records []globodns.Record, name, rtype string) []globodns.Record {
+	var filtered []globodns.Record
+	for _, r := range records {
+		if name != "" && !strings.EqualFold(r.Name, name) {
+			continue
+		}
+
+		if rtype != "" && !strings.EqualFold(r.Type, rtype) {
+			continue
+		}
+
+		filtered = append(filtered, r)
+	}
+
+	return filtered
+}
+
+func recordID(s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid record ID %q", s)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,330 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package config loads the settings of GloboDNS instances from a shared
+// file, so every tool built on the client is configured the same way.
+//
+//	current_profile: staging
+//	profiles:
+//	  production:
+//	    url: https://globodns.example.com
+//	    token_file: ~/.globodns/production-token
+//	  staging:
+//	    url: https://globodns.staging.example.com
+//	    email: ops@example.com
+//	    password_env: GLOBODNS_STAGING_PASSWORD
+//	    view: internal
+//	    timeout: 10s
+//
+// The file lives at $XDG_CONFIG_HOME/globodns/config.yaml, which defaults to
+// ~/.config/globodns/config.yaml. The following environment variables
+// override it:
+//
+//	GLOBODNS_CONFIG      path of the file
+//	GLOBODNS_PROFILE     profile to use
+//	GLOBODNS_URL         URL of GloboDNS
+//	GLOBODNS_TOKEN       authentication token
+//	GLOBODNS_EMAIL       email to sign in with
+//	GLOBODNS_PASSWORD    password to sign in with
+//	GLOBODNS_VIEW        default view
+//	GLOBODNS_TIMEOUT     HTTP timeout, e.g. 30s
+//	GLOBODNS_USER_AGENT  user agent of the requests
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+)
+
+// DefaultProfile is used when no profile is chosen.
+const DefaultProfile = "default"
+
+type Config struct {
+	CurrentProfile string              `yaml:"current_profile,omitempty"`
+	Profiles       map[string]*Profile `yaml:"profiles"`
+}
+
+// Profile holds the settings of a GloboDNS instance. The token is taken from
+// Token, TokenEnv or TokenFile, in this order; without any, the client signs
+// in with Email and a password taken likewise.
+type Profile struct {
+	URL string `yaml:"url"`
+
+	Token     string `yaml:"token,omitempty"`
+	TokenEnv  string `yaml:"token_env,omitempty"`
+	TokenFile string `yaml:"token_file,omitempty"`
+
+	Email        string `yaml:"email,omitempty"`
+	Password     string `yaml:"password,omitempty"`
+	PasswordEnv  string `yaml:"password_env,omitempty"`
+	PasswordFile string `yaml:"password_file,omitempty"`
+
+	// View is the default view for tools listing domains.
+	View string `yaml:"view,omitempty"`
+
+	Timeout            time.Duration `yaml:"timeout,omitempty"`
+	ExportPollInterval time.Duration `yaml:"export_poll_interval,omitempty"`
+	UserAgent          string        `yaml:"user_agent,omitempty"`
+
+	// Name is the name of the profile in the file.
+	Name string `yaml:"-"`
+
+	getenv func(string) string
+}
+
+// Parse decodes a config file.
+func Parse(r io.Reader) (*Config, error) {
+	var c Config
+
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&c); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("config: could not decode config: %w", err)
+	}
+
+	return &c, nil
+}
+
+// LoadFile reads the config file at path.
+func LoadFile(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	c, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%w (%s)", err, path)
+	}
+
+	return c, nil
+}
+
+// Loader finds the config file and picks a profile from it.
+type Loader struct {
+	// Path of the config file, GLOBODNS_CONFIG or the default location if
+	// empty. A missing file at the default location is not an error.
+	Path string
+
+	// Getenv reads environment variables, defaults to os.Getenv.
+	Getenv func(string) string
+}
+
+// Load returns the given profile, or the one named by GLOBODNS_PROFILE, the
+// current one in the file or DefaultProfile if empty, with environment
+// overrides applied.
+func (l *Loader) Load(name string) (*Profile, error) {
+	getenv := l.Getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+
+	c, err := l.config(getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	explicit := true
+	for _, n := range []string{name, getenv("GLOBODNS_PROFILE"), c.CurrentProfile} {
+		if n != "" {
+			name = n
+			break
+		}
+	}
+
+	if name == "" {
+		name, explicit = DefaultProfile, false
+	}
+
+	p, found := c.Profiles[name]
+	if !found && explicit {
+		return nil, fmt.Errorf("config: profile %q not found", name)
+	}
+
+	if p == nil {
+		p = &Profile{}
+	}
+
+	p.Name = name
+	p.getenv = getenv
+
+	if err = p.override(getenv); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (l *Loader) config(getenv func(string) string) (*Config, error) {
+	path := l.Path
+	if path == "" {
+		path = getenv("GLOBODNS_CONFIG")
+	}
+
+	if path != "" {
+		return LoadFile(expandHome(path, getenv))
+	}
+
+	c, err := LoadFile(DefaultPath(getenv))
+	if os.IsNotExist(err) {
+		return &Config{}, nil
+	}
+
+	return c, err
+}
+
+// DefaultPath returns where the config file is looked for when none is
+// given.
+func DefaultPath(getenv func(string) string) string {
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+
+	dir := getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		dir = filepath.Join(getenv("HOME"), ".config")
+	}
+
+	return filepath.Join(dir, "globodns", "config.yaml")
+}
+
+func (p *Profile) override(getenv func(string) string) error {
+	for env, field := range map[string]*string{
+		"GLOBODNS_URL":        &p.URL,
+		"GLOBODNS_EMAIL":      &p.Email,
+		"GLOBODNS_PASSWORD":   &p.Password,
+		"GLOBODNS_VIEW":       &p.View,
+		"GLOBODNS_USER_AGENT": &p.UserAgent,
+	} {
+		if v := getenv(env); v != "" {
+			*field = v
+		}
+	}
+
+	if token := getenv("GLOBODNS_TOKEN"); token != "" {
+		p.SetToken(token)
+	}
+
+	if v := getenv("GLOBODNS_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("config: invalid GLOBODNS_TIMEOUT: %w", err)
+		}
+
+		p.Timeout = d
+	}
+
+	return nil
+}
+
+// SetToken makes the profile use token, discarding the other token sources.
+func (p *Profile) SetToken(token string) {
+	p.Token, p.TokenEnv, p.TokenFile = token, "", ""
+}
+
+// Client builds a client for the profile, signing in when it has no token.
+func (p *Profile) Client(ctx context.Context) (*globodns.Client, error) {
+	if p.URL == "" {
+		return nil, fmt.Errorf("config: URL of profile %q is not set", p.Name)
+	}
+
+	client, err := globodns.New(&http.Client{Timeout: p.Timeout}, p.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.UserAgent != "" {
+		client.SetUserAgent(p.UserAgent)
+	}
+
+	if p.ExportPollInterval > 0 {
+		client.SetExportPollInterval(p.ExportPollInterval)
+	}
+
+	token, err := p.secret("token", p.Token, p.TokenEnv, p.TokenFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if token != "" {
+		client.SetToken(token)
+		return client, nil
+	}
+
+	if p.Email == "" {
+		return client, nil
+	}
+
+	password, err := p.secret("password", p.Password, p.PasswordEnv, p.PasswordFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = client.SignIn(ctx, p.Email, password); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+func (p *Profile) secret(what, value, env, file string) (string, error) {
+	getenv := p.getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+
+	switch {
+	case value != "":
+		return value, nil
+
+	case env != "":
+		v := getenv(env)
+		if v == "" {
+			return "", fmt.Errorf("config: %s of profile %q: environment variable %s is empty", what, p.Name, env)
+		}
+
+		return v, nil
+
+	case file != "":
+		data, err := ioutil.ReadFile(expandHome(file, getenv))
+		if err != nil {
+			return "", fmt.Errorf("config: %s of profile %q: %w", what, p.Name, err)
+		}
+
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	return "", nil
+}
+
+func expandHome(path string, getenv func(string) string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		return filepath.Join(getenv("HOME"), path[1:])
+	}
+
+	return path
+}
+
+// NewClient loads the given profile from the default config file and builds
+// its client.
+func NewClient(ctx context.Context, profile string) (*globodns.Client, error) {
+	p, err := (&Loader{}).Load(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Client(ctx)
+}
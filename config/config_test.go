@@ -0,0 +1,210 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsuru/go-globodnsclient/config"
+	"github.com/tsuru/go-globodnsclient/fake"
+	"github.com/tsuru/go-globodnsclient/fake/server"
+)
+
+const configFile = `current_profile: staging
+profiles:
+  production:
+    url: https://globodns.example.com
+    token_file: ~/production-token
+  staging:
+    url: https://globodns.staging.example.com
+    email: ops@example.com
+    password_env: STAGING_PASSWORD
+    view: internal
+    timeout: 10s
+    user_agent: my-tool
+`
+
+func env(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func writeConfig(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "globodns-config")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := config.DefaultPath(env(map[string]string{"HOME": dir}))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return dir
+}
+
+func TestParse(t *testing.T) {
+	c, err := config.Parse(strings.NewReader(configFile))
+	require.NoError(t, err)
+	assert.Equal(t, "staging", c.CurrentProfile)
+	assert.Equal(t, &config.Profile{
+		URL:         "https://globodns.staging.example.com",
+		Email:       "ops@example.com",
+		PasswordEnv: "STAGING_PASSWORD",
+		View:        "internal",
+		Timeout:     10 * time.Second,
+		UserAgent:   "my-tool",
+	}, c.Profiles["staging"])
+
+	_, err = config.Parse(strings.NewReader("profiles:\n  default:\n    uri: https://globodns.example.com\n"))
+	assert.Error(t, err)
+}
+
+func TestLoader_Load(t *testing.T) {
+	home := writeConfig(t, configFile)
+
+	tests := map[string]struct {
+		name          string
+		env           map[string]string
+		expected      config.Profile
+		expectedError string
+	}{
+		"current profile": {
+			expected: config.Profile{
+				Name:        "staging",
+				URL:         "https://globodns.staging.example.com",
+				Email:       "ops@example.com",
+				PasswordEnv: "STAGING_PASSWORD",
+				View:        "internal",
+				Timeout:     10 * time.Second,
+				UserAgent:   "my-tool",
+			},
+		},
+
+		"profile from the environment": {
+			env: map[string]string{"GLOBODNS_PROFILE": "production"},
+			expected: config.Profile{
+				Name:      "production",
+				URL:       "https://globodns.example.com",
+				TokenFile: "~/production-token",
+			},
+		},
+
+		"given profile with overrides": {
+			name: "production",
+			env: map[string]string{
+				"GLOBODNS_PROFILE": "staging",
+				"GLOBODNS_URL":     "http://localhost:3000",
+				"GLOBODNS_TOKEN":   "s3cr3t",
+				"GLOBODNS_TIMEOUT": "1m",
+			},
+			expected: config.Profile{
+				Name:    "production",
+				URL:     "http://localhost:3000",
+				Token:   "s3cr3t",
+				Timeout: time.Minute,
+			},
+		},
+
+		"unknown profile": {
+			name:          "lab",
+			expectedError: `config: profile "lab" not found`,
+		},
+
+		"invalid timeout": {
+			env:           map[string]string{"GLOBODNS_TIMEOUT": "soon"},
+			expectedError: `config: invalid GLOBODNS_TIMEOUT: time: invalid duration "soon"`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			vars := map[string]string{"HOME": home}
+			for k, v := range tt.env {
+				vars[k] = v
+			}
+
+			p, err := (&config.Loader{Getenv: env(vars)}).Load(tt.name)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected.Name, p.Name)
+			assert.Equal(t, tt.expected.URL, p.URL)
+			assert.Equal(t, tt.expected.Token, p.Token)
+			assert.Equal(t, tt.expected.TokenFile, p.TokenFile)
+			assert.Equal(t, tt.expected.Email, p.Email)
+			assert.Equal(t, tt.expected.PasswordEnv, p.PasswordEnv)
+			assert.Equal(t, tt.expected.View, p.View)
+			assert.Equal(t, tt.expected.Timeout, p.Timeout)
+			assert.Equal(t, tt.expected.UserAgent, p.UserAgent)
+		})
+	}
+}
+
+func TestLoader_LoadWithoutFile(t *testing.T) {
+	p, err := (&config.Loader{Getenv: env(map[string]string{"HOME": "/nonexistent", "GLOBODNS_URL": "http://localhost:3000"})}).Load("")
+	require.NoError(t, err)
+	assert.Equal(t, config.DefaultProfile, p.Name)
+	assert.Equal(t, "http://localhost:3000", p.URL)
+
+	_, err = (&config.Loader{Path: "/nonexistent/config.yaml"}).Load("")
+	assert.Error(t, err)
+}
+
+func TestProfile_Client(t *testing.T) {
+	b := fake.NewBackend()
+	s := server.New(b)
+	defer s.Close()
+	s.SetToken("s3cr3t")
+	s.AddUser("ops@example.com", "p4ssw0rd")
+
+	home := writeConfig(t, `profiles:
+  token:
+    url: `+s.URL+`
+    token_file: ~/token
+  credentials:
+    url: `+s.URL+`
+    email: ops@example.com
+    password_env: OPS_PASSWORD
+  missing:
+    url: `+s.URL+`
+    token_env: MISSING_TOKEN
+`)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(home, "token"), []byte("s3cr3t\n"), 0600))
+
+	loader := &config.Loader{Getenv: env(map[string]string{"HOME": home, "OPS_PASSWORD": "p4ssw0rd"})}
+	ctx := context.TODO()
+
+	for _, name := range []string{"token", "credentials"} {
+		t.Run(name, func(t *testing.T) {
+			p, err := loader.Load(name)
+			require.NoError(t, err)
+
+			client, err := p.Client(ctx)
+			require.NoError(t, err)
+
+			_, err = client.Domain.List(ctx, nil)
+			require.NoError(t, err)
+		})
+	}
+
+	p, err := loader.Load("missing")
+	require.NoError(t, err)
+
+	_, err = p.Client(ctx)
+	assert.EqualError(t, err, `config: token of profile "missing": environment variable MISSING_TOKEN is empty`)
+
+	p.URL = ""
+	_, err = p.Client(ctx)
+	assert.EqualError(t, err, `config: URL of profile "missing" is not set`)
+}
@@ -0,0 +1,314 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package dnsserver implements a small authoritative DNS server answering
+// from GloboDNS data, so records created through the client can be resolved
+// in integration tests.
+package dnsserver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/zonefile"
+)
+
+// DefaultTTL is used for records whose TTL is set neither on them nor on
+// their domain.
+const DefaultTTL = 300
+
+// maxCNAMEChain limits how many CNAME records are followed in an answer.
+const maxCNAMEChain = 8
+
+var supportedTypes = map[uint16]bool{
+	dns.TypeA:     true,
+	dns.TypeAAAA:  true,
+	dns.TypeCNAME: true,
+	dns.TypeMX:    true,
+	dns.TypeNS:    true,
+	dns.TypePTR:   true,
+	dns.TypeSOA:   true,
+	dns.TypeSRV:   true,
+	dns.TypeTXT:   true,
+}
+
+// Server answers A, AAAA, CNAME, MX, TXT, SRV, PTR, NS and SOA queries for the
+// domains of its source over UDP and TCP. Names out of those domains are
+// refused.
+type Server struct {
+	Source Source
+
+	// Timeout bounds how long fetching data from the source may take,
+	// defaults to 5 seconds.
+	Timeout time.Duration
+
+	mu   sync.Mutex
+	addr string
+	udp  *dns.Server
+	tcp  *dns.Server
+}
+
+func New(src Source) *Server {
+	return &Server{Source: src}
+}
+
+// Start listens on addr over both UDP and TCP. Port 0 picks a free port,
+// the same for both, which is then reported by Addr.
+func (s *Server) Start(addr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.udp != nil {
+		return fmt.Errorf("dnsserver: server already started")
+	}
+
+	l, pc, err := listen(addr)
+	if err != nil {
+		return err
+	}
+
+	s.addr = l.Addr().String()
+	s.tcp = &dns.Server{Listener: l, Handler: s}
+	s.udp = &dns.Server{PacketConn: pc, Handler: s}
+
+	for _, srv := range []*dns.Server{s.tcp, s.udp} {
+		started := make(chan struct{})
+		srv.NotifyStartedFunc = func() { close(started) }
+
+		go srv.ActivateAndServe()
+		<-started
+	}
+
+	return nil
+}
+
+func listen(addr string) (net.Listener, net.PacketConn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("dnsserver: invalid address %q: %w", addr, err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		_, actual, _ := net.SplitHostPort(l.Addr().String())
+
+		pc, err := net.ListenPacket("udp", net.JoinHostPort(host, actual))
+		if err == nil {
+			return l, pc, nil
+		}
+
+		l.Close()
+
+		// The free TCP port may be taken for UDP, try another one.
+		if port != "0" || attempt == 9 {
+			return nil, nil, err
+		}
+	}
+}
+
+// Addr returns the address the server is listening on, both for UDP and TCP.
+func (s *Server) Addr() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addr
+}
+
+// Close stops listening.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.udp == nil {
+		return nil
+	}
+
+	err := s.udp.Shutdown()
+	if tcpErr := s.tcp.Shutdown(); err == nil {
+		err = tcpErr
+	}
+
+	s.udp, s.tcp = nil, nil
+	return err
+}
+
+func (s *Server) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	res := s.answer(req)
+	w.WriteMsg(res)
+}
+
+func (s *Server) answer(req *dns.Msg) *dns.Msg {
+	res := new(dns.Msg)
+	res.SetReply(req)
+
+	if len(req.Question) != 1 {
+		res.Rcode = dns.RcodeFormatError
+		return res
+	}
+
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	data, err := s.Source.Data(ctx)
+	if err != nil {
+		res.Rcode = dns.RcodeServerFailure
+		return res
+	}
+
+	q := req.Question[0]
+
+	z := findZone(data, q.Name)
+	if z == nil {
+		res.Rcode = dns.RcodeRefused
+		return res
+	}
+
+	res.Authoritative = true
+
+	name := strings.ToLower(q.Name)
+	for i := 0; i < maxCNAMEChain; i++ {
+		rrs := z.lookup(name)
+		if len(rrs) == 0 {
+			if i == 0 {
+				res.Rcode = dns.RcodeNameError
+			}
+
+			break
+		}
+
+		answers, cname := filter(rrs, q.Qtype)
+		res.Answer = append(res.Answer, answers...)
+
+		if cname == nil || q.Qtype == dns.TypeCNAME {
+			break
+		}
+
+		res.Answer = append(res.Answer, cname)
+		name = strings.ToLower(cname.(*dns.CNAME).Target)
+
+		if !dns.IsSubDomain(z.origin, name) {
+			break
+		}
+	}
+
+	if len(res.Answer) == 0 && z.soa != nil {
+		res.Ns = append(res.Ns, z.soa)
+	}
+
+	return res
+}
+
+func filter(rrs []dns.RR, qtype uint16) ([]dns.RR, dns.RR) {
+	var answers []dns.RR
+	var cname dns.RR
+
+	for _, rr := range rrs {
+		t := rr.Header().Rrtype
+		switch {
+		case t == qtype || qtype == dns.TypeANY:
+			answers = append(answers, rr)
+		case t == dns.TypeCNAME:
+			cname = rr
+		}
+	}
+
+	if len(answers) > 0 {
+		return answers, nil
+	}
+
+	return nil, cname
+}
+
+type zone struct {
+	origin string
+	soa    dns.RR
+	names  map[string][]dns.RR
+}
+
+func (z *zone) lookup(name string) []dns.RR {
+	return z.names[name]
+}
+
+// findZone builds the zone of the most specific domain containing name.
+func findZone(data *Data, name string) *zone {
+	name = strings.ToLower(name)
+
+	var best *globodns.Domain
+	for i := range data.Domains {
+		d := &data.Domains[i]
+		origin := dns.Fqdn(strings.ToLower(d.Name))
+
+		if !dns.IsSubDomain(origin, name) {
+			continue
+		}
+
+		if best == nil || dns.CountLabel(origin) > dns.CountLabel(dns.Fqdn(best.Name)) {
+			best = d
+		}
+	}
+
+	if best == nil {
+		return nil
+	}
+
+	z := &zone{origin: dns.Fqdn(strings.ToLower(best.Name)), names: make(map[string][]dns.RR)}
+	for _, r := range data.Records {
+		if r.DomainID != best.ID {
+			continue
+		}
+
+		rr := newRR(*best, r, z.origin)
+		if rr == nil || !supportedTypes[rr.Header().Rrtype] {
+			continue
+		}
+
+		owner := strings.ToLower(rr.Header().Name)
+		z.names[owner] = append(z.names[owner], rr)
+
+		if rr.Header().Rrtype == dns.TypeSOA && owner == z.origin {
+			z.soa = rr
+		}
+	}
+
+	return z
+}
+
+// newRR parses r as a master file entry, nil if its content is invalid.
+func newRR(d globodns.Domain, r globodns.Record, origin string) dns.RR {
+	ttl := globodns.TTL(r, d)
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	owner := r.Name
+	if owner == "" {
+		owner = "@"
+	}
+
+	entry := fmt.Sprintf("%s %d IN %s %s", owner, ttl, strings.ToUpper(r.Type), zonefile.RData(r))
+
+	zp := dns.NewZoneParser(strings.NewReader(entry), origin, "")
+	rr, ok := zp.Next()
+	if !ok || zp.Err() != nil {
+		return nil
+	}
+
+	return rr
+}
@@ -0,0 +1,196 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dnsserver_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/dnsserver"
+	"github.com/tsuru/go-globodnsclient/fake"
+)
+
+func newServer(t *testing.T, src dnsserver.Source) *dnsserver.Server {
+	s := dnsserver.New(src)
+	require.NoError(t, s.Start("127.0.0.1:0"))
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func query(t *testing.T, s *dnsserver.Server, network, name string, qtype uint16) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetQuestion(name, qtype)
+
+	c := &dns.Client{Net: network}
+	res, _, err := c.Exchange(m, s.Addr())
+	require.NoError(t, err)
+	return res
+}
+
+func answers(m *dns.Msg) []string {
+	var rrs []string
+	for _, rr := range m.Answer {
+		rrs = append(rrs, strings.Replace(rr.String(), "\t", " ", -1))
+	}
+
+	return rrs
+}
+
+func TestServer(t *testing.T) {
+	prio := 10
+	domains := []globodns.Domain{
+		{ID: 1, Name: "example.com", TTL: globodns.StringPointer("3600")},
+		{ID: 2, Name: "100.196.169.in-addr.arpa", AddressingType: "R"},
+		{ID: 3, Name: "sub.example.com"},
+	}
+	records := []globodns.Record{
+		{DomainID: 1, Name: "@", Type: "SOA", Content: "ns1.example.com. hostmaster.example.com. 2021102901 10800 3600 604800 3600"},
+		{DomainID: 1, Name: "@", Type: "MX", Content: "mail", Prio: &prio},
+		{DomainID: 1, Name: "www", Type: "A", Content: "169.196.100.100", TTL: globodns.StringPointer("300")},
+		{DomainID: 1, Name: "www", Type: "AAAA", Content: "2001:db8::1"},
+		{DomainID: 1, Name: "www", Type: "TXT", Content: `hello "world"`},
+		{DomainID: 1, Name: "api", Type: "CNAME", Content: "www"},
+		{DomainID: 1, Name: "_sip._tcp", Type: "SRV", Content: "5 5060 sip.example.com.", Prio: &prio},
+		{DomainID: 1, Name: "broken", Type: "A", Content: "not an address"},
+		{DomainID: 2, Name: "100", Type: "PTR", Content: "www.example.com."},
+		{DomainID: 3, Name: "www", Type: "A", Content: "169.196.100.200"},
+	}
+
+	s := newServer(t, dnsserver.Static(domains, records))
+
+	tests := map[string]struct {
+		name          string
+		qtype         uint16
+		expected      []string
+		expectedRcode int
+		expectedNs    int
+	}{
+		"A record": {
+			name:     "www.example.com.",
+			qtype:    dns.TypeA,
+			expected: []string{"www.example.com. 300 IN A 169.196.100.100"},
+		},
+
+		"AAAA record using the domain TTL": {
+			name:     "WWW.Example.com.",
+			qtype:    dns.TypeAAAA,
+			expected: []string{"www.example.com. 3600 IN AAAA 2001:db8::1"},
+		},
+
+		"TXT record": {
+			name:     "www.example.com.",
+			qtype:    dns.TypeTXT,
+			expected: []string{`www.example.com. 3600 IN TXT "hello \"world\""`},
+		},
+
+		"MX record with a relative target": {
+			name:     "example.com.",
+			qtype:    dns.TypeMX,
+			expected: []string{"example.com. 3600 IN MX 10 mail.example.com."},
+		},
+
+		"SRV record": {
+			name:     "_sip._tcp.example.com.",
+			qtype:    dns.TypeSRV,
+			expected: []string{"_sip._tcp.example.com. 3600 IN SRV 10 5 5060 sip.example.com."},
+		},
+
+		"CNAME followed within the zone": {
+			name:  "api.example.com.",
+			qtype: dns.TypeA,
+			expected: []string{
+				"api.example.com. 3600 IN CNAME www.example.com.",
+				"www.example.com. 300 IN A 169.196.100.100",
+			},
+		},
+
+		"PTR record": {
+			name:     "100.100.196.169.in-addr.arpa.",
+			qtype:    dns.TypePTR,
+			expected: []string{"100.100.196.169.in-addr.arpa. 300 IN PTR www.example.com."},
+		},
+
+		"most specific domain": {
+			name:     "www.sub.example.com.",
+			qtype:    dns.TypeA,
+			expected: []string{"www.sub.example.com. 300 IN A 169.196.100.200"},
+		},
+
+		"no data": {
+			name:       "www.example.com.",
+			qtype:      dns.TypeMX,
+			expectedNs: 1,
+		},
+
+		"unknown name": {
+			name:          "unknown.example.com.",
+			qtype:         dns.TypeA,
+			expectedRcode: dns.RcodeNameError,
+			expectedNs:    1,
+		},
+
+		"invalid content is left out": {
+			name:          "broken.example.com.",
+			qtype:         dns.TypeA,
+			expectedRcode: dns.RcodeNameError,
+			expectedNs:    1,
+		},
+
+		"name out of the served domains": {
+			name:          "www.example.org.",
+			qtype:         dns.TypeA,
+			expectedRcode: dns.RcodeRefused,
+		},
+	}
+
+	for name, tt := range tests {
+		for _, network := range []string{"udp", "tcp"} {
+			t.Run(fmt.Sprintf("%s over %s", name, network), func(t *testing.T) {
+				res := query(t, s, network, tt.name, tt.qtype)
+				assert.Equal(t, tt.expectedRcode, res.Rcode)
+				assert.Equal(t, tt.expected, answers(res))
+				assert.Len(t, res.Ns, tt.expectedNs)
+				assert.Equal(t, tt.expectedRcode != dns.RcodeRefused, res.Authoritative)
+			})
+		}
+	}
+}
+
+func TestServer_FromBackend(t *testing.T) {
+	b := fake.NewBackend()
+	client := b.Client()
+	ctx := context.TODO()
+
+	d, err := client.Domain.Create(ctx, globodns.Domain{Name: "example.com"})
+	require.NoError(t, err)
+
+	s := newServer(t, dnsserver.FromBackend(b))
+
+	res := query(t, s, "udp", "www.example.com.", dns.TypeA)
+	assert.Equal(t, dns.RcodeNameError, res.Rcode)
+
+	_, err = client.Record.Create(ctx, globodns.Record{DomainID: d.ID, Name: "www", Type: "A", Content: "169.196.100.100"})
+	require.NoError(t, err)
+
+	res = query(t, s, "udp", "www.example.com.", dns.TypeA)
+	assert.Equal(t, []string{"www.example.com. 300 IN A 169.196.100.100"}, answers(res))
+
+	res = query(t, s, "tcp", "www.example.com.", dns.TypeA)
+	assert.Equal(t, []string{"www.example.com. 300 IN A 169.196.100.100"}, answers(res))
+}
+
+func TestServer_SourceError(t *testing.T) {
+	s := newServer(t, dnsserver.FromServices(fake.New().Domain, fake.New().Record))
+
+	res := query(t, s, "udp", "www.example.com.", dns.TypeA)
+	assert.Equal(t, dns.RcodeServerFailure, res.Rcode)
+}
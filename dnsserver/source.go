@@ -0,0 +1,70 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dnsserver
+
+import (
+	"context"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/fake"
+)
+
+// Data is the set of domains and records answered by a Server.
+type Data struct {
+	Domains []globodns.Domain
+	Records []globodns.Record
+}
+
+// Source provides the data to answer each query, so changes made after the
+// server started are seen right away.
+type Source interface {
+	Data(ctx context.Context) (*Data, error)
+}
+
+type SourceFunc func(ctx context.Context) (*Data, error)
+
+func (f SourceFunc) Data(ctx context.Context) (*Data, error) {
+	return f(ctx)
+}
+
+// Static serves a fixed snapshot of domains and records.
+func Static(domains []globodns.Domain, records []globodns.Record) Source {
+	data := &Data{Domains: domains, Records: records}
+
+	return SourceFunc(func(context.Context) (*Data, error) {
+		return data, nil
+	})
+}
+
+// FromBackend serves the current state of an in-memory fake backend.
+func FromBackend(b *fake.Backend) Source {
+	return SourceFunc(func(context.Context) (*Data, error) {
+		s := b.Snapshot()
+		return &Data{Domains: s.Domains, Records: s.Records}, nil
+	})
+}
+
+// FromServices lists every domain and their records on each query. Use the
+// services of a cache.Cache to avoid hitting GloboDNS that often.
+func FromServices(domains globodns.DomainService, records globodns.RecordService) Source {
+	return SourceFunc(func(ctx context.Context) (*Data, error) {
+		ds, err := domains.List(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		data := &Data{Domains: ds}
+		for _, d := range ds {
+			rs, err := records.List(ctx, d.ID, nil)
+			if err != nil {
+				return nil, err
+			}
+
+			data.Records = append(data.Records, rs...)
+		}
+
+		return data, nil
+	})
+}
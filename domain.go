@@ -5,7 +5,9 @@
 package globodns
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -89,7 +91,12 @@ func (p *ListDomainsParameters) AsURLValues() url.Values {
 }
 
 type DomainService interface {
+	Create(ctx context.Context, d Domain) (*Domain, error)
+	Delete(ctx context.Context, domainID int) error
+	Get(ctx context.Context, domainID int) (*Domain, error)
 	List(ctx context.Context, p *ListDomainsParameters) ([]Domain, error)
+	ListPage(ctx context.Context, p *ListDomainsParameters) (*DomainPage, error)
+	Update(ctx context.Context, d Domain) error
 }
 
 var _ DomainService = &domainService{}
@@ -102,6 +109,109 @@ type domainService struct {
 	*Client
 }
 
+func (d *domainService) Create(ctx context.Context, domain Domain) (*Domain, error) {
+	if domain.Name == "" {
+		return nil, fmt.Errorf("globodns: domain name cannot be empty")
+	}
+
+	if err := d.authorize(ctx, PolicyRequest{Operation: OperationCreateDomain, Domain: &domain}); err != nil {
+		return nil, err
+	}
+
+	return d.create(ctx, domain)
+}
+
+func (d *domainService) create(ctx context.Context, domain Domain) (*Domain, error) {
+	var body bytes.Buffer
+
+	data := map[string]Domain{"domain": domain}
+
+	if err := json.NewEncoder(&body).Encode(&data); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", d.makeURL("/domains.json"), &body)
+	if err != nil {
+		return nil, err
+	}
+
+	var got struct {
+		Domain *Domain `json:"domain"`
+	}
+
+	if _, err = d.Do(req, &got); err != nil {
+		return nil, err
+	}
+
+	if got.Domain == nil {
+		return nil, fmt.Errorf("globodns: domain not found in the response")
+	}
+
+	return got.Domain, nil
+}
+
+func (d *domainService) Delete(ctx context.Context, domainID int) error {
+	if domainID < 0 {
+		return fmt.Errorf("globodns: domain ID cannot be negative")
+	}
+
+	if d.policy != nil {
+		current, err := d.get(ctx, domainID)
+		if err != nil {
+			return err
+		}
+
+		if err = d.authorize(ctx, PolicyRequest{Operation: OperationDeleteDomain, Domain: current}); err != nil {
+			return err
+		}
+	}
+
+	return d.delete(ctx, domainID)
+}
+
+func (d *domainService) delete(ctx context.Context, domainID int) error {
+	path := fmt.Sprintf("/domains/%d.json", domainID)
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", d.makeURL(path), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.Do(req, nil)
+	return err
+}
+
+func (d *domainService) Get(ctx context.Context, domainID int) (*Domain, error) {
+	if domainID < 0 {
+		return nil, fmt.Errorf("globodns: domain ID cannot be negative")
+	}
+
+	return d.get(ctx, domainID)
+}
+
+func (d *domainService) get(ctx context.Context, domainID int) (*Domain, error) {
+	path := fmt.Sprintf("/domains/%d.json", domainID)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", d.makeURL(path), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var got struct {
+		Domain *Domain `json:"domain"`
+	}
+
+	if _, err = d.Do(req, &got); err != nil {
+		return nil, err
+	}
+
+	if got.Domain == nil {
+		return nil, fmt.Errorf("globodns: domain %d not found in the response", domainID)
+	}
+
+	return got.Domain, nil
+}
+
 func (d *domainService) List(ctx context.Context, p *ListDomainsParameters) ([]Domain, error) {
 	if err := p.Validate(); err != nil {
 		return nil, err
@@ -139,7 +249,33 @@ func (d *domainService) listAll(ctx context.Context, p *ListDomainsParameters) (
 	return domains, nil
 }
 
+func (d *domainService) ListPage(ctx context.Context, p *ListDomainsParameters) (*DomainPage, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	params := ListDomainsParameters{Page: 1}
+	if p != nil {
+		params = *p
+	}
+
+	if params.Page == 0 {
+		params.Page = 1
+	}
+
+	return d.listPage(ctx, &params)
+}
+
 func (d *domainService) list(ctx context.Context, p *ListDomainsParameters) ([]Domain, error) {
+	page, err := d.listPage(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Domains, nil
+}
+
+func (d *domainService) listPage(ctx context.Context, p *ListDomainsParameters) (*DomainPage, error) {
 	path := fmt.Sprintf("/domains?%s", p.AsURLValues().Encode())
 
 	req, err := http.NewRequestWithContext(ctx, "GET", d.makeURL(path), nil)
@@ -151,7 +287,7 @@ func (d *domainService) list(ctx context.Context, p *ListDomainsParameters) ([]D
 		Domain Domain `json:"domain"`
 	}
 
-	_, err = d.Do(req, &got)
+	res, err := d.Do(req, &got)
 	if err != nil {
 		return nil, err
 	}
@@ -161,5 +297,47 @@ func (d *domainService) list(ctx context.Context, p *ListDomainsParameters) ([]D
 		domains = append(domains, s.Domain)
 	}
 
-	return domains, nil
+	return &DomainPage{
+		Pagination: newPagination(p.Page, p.PerPage, len(domains), res.Header),
+		Domains:    domains,
+	}, nil
+}
+
+func (d *domainService) Update(ctx context.Context, domain Domain) error {
+	if domain.ID < 0 {
+		return fmt.Errorf("globodns: domain ID cannot be negative")
+	}
+
+	if d.policy != nil {
+		current, err := d.get(ctx, domain.ID)
+		if err != nil {
+			return err
+		}
+
+		if err = d.authorize(ctx, PolicyRequest{Operation: OperationUpdateDomain, Domain: &domain, CurrentDomain: current}); err != nil {
+			return err
+		}
+	}
+
+	return d.update(ctx, domain)
+}
+
+func (d *domainService) update(ctx context.Context, domain Domain) error {
+	var body bytes.Buffer
+
+	data := map[string]Domain{"domain": domain}
+
+	if err := json.NewEncoder(&body).Encode(&data); err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/domains/%d.json", domain.ID)
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", d.makeURL(path), &body)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.Do(req, nil)
+	return err
 }
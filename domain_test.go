@@ -6,6 +6,7 @@ package globodns_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -130,3 +131,244 @@ func TestClient_DomainList(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_DomainListPage(t *testing.T) {
+	tests := map[string]struct {
+		handler       http.HandlerFunc
+		parameters    *globodns.ListDomainsParameters
+		expected      *globodns.DomainPage
+		expectedError string
+	}{
+		"page < 0": {
+			parameters:    &globodns.ListDomainsParameters{Page: -1},
+			expectedError: "globodns: page cannot be negative",
+		},
+
+		"servers returns an error": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprintf(w, "some internal error")
+			},
+			expectedError: "globodns: unexpected HTTP status code: Code: 500 Body: some internal error",
+		},
+
+		"without parameters, should request the first page": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, url.Values{"page": []string{"1"}}, r.URL.Query())
+				fmt.Fprintf(w, `[{"domain": {"id": 1, "name": "example.com"}}]`)
+			},
+			expected: &globodns.DomainPage{
+				Pagination: globodns.Pagination{Page: 1},
+				Domains:    []globodns.Domain{{ID: 1, Name: "example.com"}},
+			},
+		},
+
+		"with total count headers": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "3", r.URL.Query().Get("page"))
+				assert.Equal(t, "1", r.URL.Query().Get("per_page"))
+
+				w.Header().Set("X-Total-Count", "40")
+				fmt.Fprintf(w, `[{"domain": {"id": 3, "name": "example.org"}}]`)
+			},
+			parameters: &globodns.ListDomainsParameters{Page: 3, PerPage: 1},
+			expected: &globodns.DomainPage{
+				Pagination: globodns.Pagination{
+					Page:       3,
+					PerPage:    1,
+					Total:      globodns.IntPointer(40),
+					TotalPages: globodns.IntPointer(40),
+					NextPage:   globodns.IntPointer(4),
+				},
+				Domains: []globodns.Domain{{ID: 3, Name: "example.org"}},
+			},
+		},
+
+		"with link header pointing to the next page": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Link", `<http://globodns.example.com/domains?page=1&per_page=2>; rel="prev", <http://globodns.example.com/domains?page=3&per_page=2>; rel="next"`)
+				fmt.Fprintf(w, `[{"domain": {"id": 3, "name": "example.org"}}, {"domain": {"id": 4, "name": "example.net"}}]`)
+			},
+			parameters: &globodns.ListDomainsParameters{Page: 2, PerPage: 2},
+			expected: &globodns.DomainPage{
+				Pagination: globodns.Pagination{Page: 2, PerPage: 2, NextPage: globodns.IntPointer(3)},
+				Domains:    []globodns.Domain{{ID: 3, Name: "example.org"}, {ID: 4, Name: "example.net"}},
+			},
+		},
+
+		"last page according to the total pages header": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Total-Pages", "2")
+				fmt.Fprintf(w, `[{"domain": {"id": 4, "name": "example.net"}}]`)
+			},
+			parameters: &globodns.ListDomainsParameters{Page: 2, PerPage: 1},
+			expected: &globodns.DomainPage{
+				Pagination: globodns.Pagination{Page: 2, PerPage: 1, TotalPages: globodns.IntPointer(2)},
+				Domains:    []globodns.Domain{{ID: 4, Name: "example.net"}},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			client, err := globodns.New(nil, server.URL)
+			require.NoError(t, err)
+
+			got, err := client.Domain.ListPage(context.TODO(), tt.parameters)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestClient_DomainGet(t *testing.T) {
+	tests := map[string]struct {
+		handler       http.HandlerFunc
+		domainID      int
+		expected      *globodns.Domain
+		expectedError string
+	}{
+		"domain id < 0": {
+			domainID:      -1,
+			expectedError: "globodns: domain ID cannot be negative",
+		},
+
+		"domain not found": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintf(w, `{"error": "NOT FOUND"}`)
+			},
+			domainID:      666,
+			expectedError: `globodns: unexpected HTTP status code: Code: 404 Body: {"error": "NOT FOUND"}`,
+		},
+
+		"getting a domain": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "GET", r.Method)
+				assert.Equal(t, "/domains/1.json", r.URL.Path)
+				fmt.Fprintf(w, `{"domain": {"id": 1, "name": "example.com", "ttl": "86400", "authority_type": "M", "addressing_type": "N", "view_id": 2}}`)
+			},
+			domainID: 1,
+			expected: &globodns.Domain{ID: 1, Name: "example.com", TTL: globodns.StringPointer("86400"), AuthorityType: "M", AddressingType: "N", ViewID: 2},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			client, err := globodns.New(nil, server.URL)
+			require.NoError(t, err)
+
+			got, err := client.Domain.Get(context.TODO(), tt.domainID)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestClient_DomainCreate(t *testing.T) {
+	tests := map[string]struct {
+		handler       http.HandlerFunc
+		domain        globodns.Domain
+		expected      *globodns.Domain
+		expectedError string
+	}{
+		"empty name": {
+			expectedError: "globodns: domain name cannot be empty",
+		},
+
+		"invalid domain": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				fmt.Fprintf(w, `{"errors": {"name": ["has already been taken"]}}`)
+			},
+			domain:        globodns.Domain{Name: "example.com"},
+			expectedError: `globodns: unexpected HTTP status code: Code: 422 Body: {"errors": {"name": ["has already been taken"]}}`,
+		},
+
+		"creating a domain": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "POST", r.Method)
+				assert.Equal(t, "/domains.json", r.URL.Path)
+
+				var body map[string]map[string]interface{}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				assert.Equal(t, "example.com", body["domain"]["name"])
+				assert.Equal(t, "M", body["domain"]["authority_type"])
+
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprintf(w, `{"domain": {"id": 10, "name": "example.com", "authority_type": "M", "addressing_type": "N", "view_id": 1}}`)
+			},
+			domain:   globodns.Domain{Name: "example.com", AuthorityType: "M"},
+			expected: &globodns.Domain{ID: 10, Name: "example.com", AuthorityType: "M", AddressingType: "N", ViewID: 1},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			client, err := globodns.New(nil, server.URL)
+			require.NoError(t, err)
+
+			got, err := client.Domain.Create(context.TODO(), tt.domain)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestClient_DomainUpdateAndDelete(t *testing.T) {
+	var calls []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+
+		if r.Method == "PUT" {
+			var body map[string]map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "3600", body["domain"]["ttl"])
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client, err := globodns.New(nil, server.URL)
+	require.NoError(t, err)
+
+	err = client.Domain.Update(context.TODO(), globodns.Domain{ID: -1})
+	assert.EqualError(t, err, "globodns: domain ID cannot be negative")
+
+	err = client.Domain.Delete(context.TODO(), -1)
+	assert.EqualError(t, err, "globodns: domain ID cannot be negative")
+
+	err = client.Domain.Update(context.TODO(), globodns.Domain{ID: 10, Name: "example.com", TTL: globodns.StringPointer("3600")})
+	require.NoError(t, err)
+
+	err = client.Domain.Delete(context.TODO(), 10)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"PUT /domains/10.json", "DELETE /domains/10.json"}, calls)
+}
@@ -0,0 +1,713 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fake
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+)
+
+// DefaultPerPage is the page size used by the backend when none is requested.
+const DefaultPerPage = 25
+
+// Backend is a stateful in-memory stand-in for GloboDNS. Its services store
+// domains and records, assign IDs and timestamps, paginate listings and
+// enforce the same uniqueness rules as the server, failing with
+// *globodns.HTTPError just like the real client does.
+type Backend struct {
+	Bind   globodns.BindService
+	Domain globodns.DomainService
+	Record globodns.RecordService
+
+	// Now is used to fill timestamps, defaults to time.Now.
+	Now func() time.Time
+
+	// Recorder records every call made to the services above.
+	Recorder *Recorder
+
+	mu           sync.Mutex
+	domains      map[int]globodns.Domain
+	records      map[int]globodns.Record
+	views        map[int]string
+	nextDomainID int
+	nextRecordID int
+	lastExport   *time.Time
+}
+
+// State holds everything stored by a Backend.
+type State struct {
+	Views   map[int]string
+	Domains []globodns.Domain
+	Records []globodns.Record
+}
+
+func NewBackend() *Backend {
+	b := &Backend{Now: time.Now, Recorder: &Recorder{}}
+	b.Bind = recordBind(&backendBindService{b}, b.Recorder)
+	b.Domain = recordDomain(&backendDomainService{b}, b.Recorder)
+	b.Record = recordRecord(&backendRecordService{b}, b.Recorder)
+	b.reset()
+	return b
+}
+
+// Client returns a client whose services are backed by b.
+func (b *Backend) Client() *globodns.Client {
+	return &globodns.Client{
+		Bind:   b.Bind,
+		Domain: b.Domain,
+		Record: b.Record,
+	}
+}
+
+// SetView names the view with the given ID, which is what the View listing
+// parameter is matched against.
+func (b *Backend) SetView(id int, name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.views[id] = name
+}
+
+// Seed adds the given state to the backend, keeping the IDs already set and
+// assigning new ones otherwise.
+func (b *Backend) Seed(s State) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for id, name := range s.Views {
+		b.views[id] = name
+	}
+
+	for _, d := range s.Domains {
+		if _, err := b.seedDomain(d); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range s.Records {
+		if err := b.seedRecord(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *Backend) seedDomain(d globodns.Domain) (globodns.Domain, error) {
+	if d.ID == 0 {
+		b.nextDomainID++
+		d.ID = b.nextDomainID
+	}
+
+	if _, found := b.domains[d.ID]; found {
+		return d, fmt.Errorf("fake: domain %d already exists", d.ID)
+	}
+
+	b.domains[d.ID] = d
+	if d.ID > b.nextDomainID {
+		b.nextDomainID = d.ID
+	}
+
+	return d, nil
+}
+
+func (b *Backend) seedRecord(r globodns.Record) error {
+	if _, found := b.domains[r.DomainID]; !found {
+		return fmt.Errorf("fake: domain %d of record %s not found", r.DomainID, r.Name)
+	}
+
+	if r.ID == 0 {
+		b.nextRecordID++
+		r.ID = b.nextRecordID
+	}
+
+	if _, found := b.records[r.ID]; found {
+		return fmt.Errorf("fake: record %d already exists", r.ID)
+	}
+
+	r.Type = strings.ToUpper(r.Type)
+	b.records[r.ID] = r
+	if r.ID > b.nextRecordID {
+		b.nextRecordID = r.ID
+	}
+
+	return nil
+}
+
+// Snapshot returns a copy of the stored state, sorted by ID.
+func (b *Backend) Snapshot() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := State{Views: make(map[int]string)}
+	for id, name := range b.views {
+		s.Views[id] = name
+	}
+
+	for _, d := range b.domains {
+		s.Domains = append(s.Domains, d)
+	}
+
+	for _, r := range b.records {
+		s.Records = append(s.Records, r)
+	}
+
+	sort.Slice(s.Domains, func(i, j int) bool { return s.Domains[i].ID < s.Domains[j].ID })
+	sort.Slice(s.Records, func(i, j int) bool { return s.Records[i].ID < s.Records[j].ID })
+	return s
+}
+
+// Reset drops everything stored, restarting the IDs as well.
+func (b *Backend) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.reset()
+}
+
+func (b *Backend) reset() {
+	b.domains = make(map[int]globodns.Domain)
+	b.records = make(map[int]globodns.Record)
+	b.views = make(map[int]string)
+	b.nextDomainID = 0
+	b.nextRecordID = 0
+	b.lastExport = nil
+}
+
+func (b *Backend) now() time.Time {
+	if b.Now == nil {
+		return time.Now()
+	}
+
+	return b.Now()
+}
+
+func notFound() error {
+	return &globodns.HTTPError{StatusCode: 404, Body: []byte(`{"error":"NOT FOUND"}`)}
+}
+
+func unprocessable(field, message string) error {
+	return &globodns.HTTPError{StatusCode: 422, Body: []byte(fmt.Sprintf(`{"errors":{%q:[%q]}}`, field, message))}
+}
+
+func matchQuery(query, s string) bool {
+	if query == "" {
+		return true
+	}
+
+	pattern := strings.ToLower(query)
+	if !strings.Contains(pattern, "*") {
+		pattern = "*" + pattern + "*"
+	}
+
+	ok, _ := path.Match(pattern, strings.ToLower(s))
+	return ok
+}
+
+func paginate(page, perPage, total int) (int, int, globodns.Pagination) {
+	if page <= 0 {
+		page = 1
+	}
+
+	if perPage <= 0 {
+		perPage = DefaultPerPage
+	}
+
+	pages := (total + perPage - 1) / perPage
+	p := globodns.Pagination{
+		Page:       page,
+		PerPage:    perPage,
+		Total:      globodns.IntPointer(total),
+		TotalPages: globodns.IntPointer(pages),
+	}
+
+	if page < pages {
+		p.NextPage = globodns.IntPointer(page + 1)
+	}
+
+	start, end := (page-1)*perPage, page*perPage
+	if start > total {
+		start = total
+	}
+
+	if end > total {
+		end = total
+	}
+
+	return start, end, p
+}
+
+func normalizeDomainName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+func isReverse(d globodns.Domain) bool {
+	return strings.EqualFold(d.AddressingType, "R")
+}
+
+var _ globodns.DomainService = &backendDomainService{}
+
+type backendDomainService struct {
+	b *Backend
+}
+
+func (s *backendDomainService) Create(ctx context.Context, d globodns.Domain) (*globodns.Domain, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+
+	if err := s.validate(d); err != nil {
+		return nil, err
+	}
+
+	s.b.nextDomainID++
+	d.ID = s.b.nextDomainID
+	s.b.domains[d.ID] = d
+	return &d, nil
+}
+
+func (s *backendDomainService) Delete(ctx context.Context, domainID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+
+	if _, found := s.b.domains[domainID]; !found {
+		return notFound()
+	}
+
+	delete(s.b.domains, domainID)
+
+	for id, r := range s.b.records {
+		if r.DomainID == domainID {
+			delete(s.b.records, id)
+		}
+	}
+
+	return nil
+}
+
+func (s *backendDomainService) Get(ctx context.Context, domainID int) (*globodns.Domain, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+
+	d, found := s.b.domains[domainID]
+	if !found {
+		return nil, notFound()
+	}
+
+	return &d, nil
+}
+
+func (s *backendDomainService) List(ctx context.Context, p *globodns.ListDomainsParameters) ([]globodns.Domain, error) {
+	page, err := s.listPage(ctx, p, p == nil || p.Page == 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Domains, nil
+}
+
+func (s *backendDomainService) ListPage(ctx context.Context, p *globodns.ListDomainsParameters) (*globodns.DomainPage, error) {
+	return s.listPage(ctx, p, false)
+}
+
+func (s *backendDomainService) listPage(ctx context.Context, p *globodns.ListDomainsParameters, all bool) (*globodns.DomainPage, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var params globodns.ListDomainsParameters
+	if p != nil {
+		params = *p
+	}
+
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+
+	var domains []globodns.Domain
+	for _, d := range s.b.domains {
+		if s.match(d, params) {
+			domains = append(domains, d)
+		}
+	}
+
+	sort.Slice(domains, func(i, j int) bool { return domains[i].Name < domains[j].Name })
+
+	perPage := params.PerPage
+	if all {
+		params.Page, perPage = 1, len(domains)
+	}
+
+	start, end, pagination := paginate(params.Page, perPage, len(domains))
+	return &globodns.DomainPage{Pagination: pagination, Domains: domains[start:end:end]}, nil
+}
+
+func (s *backendDomainService) Update(ctx context.Context, d globodns.Domain) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+
+	current, found := s.b.domains[d.ID]
+	if !found {
+		return notFound()
+	}
+
+	if d.Name == "" {
+		d.Name = current.Name
+	}
+
+	if d.AuthorityType == "" {
+		d.AuthorityType = current.AuthorityType
+	}
+
+	if d.AddressingType == "" {
+		d.AddressingType = current.AddressingType
+	}
+
+	if d.Notes == nil {
+		d.Notes = current.Notes
+	}
+
+	if d.TTL == nil {
+		d.TTL = current.TTL
+	}
+
+	if d.ViewID == 0 {
+		d.ViewID = current.ViewID
+	}
+
+	if err := s.validate(d); err != nil {
+		return err
+	}
+
+	s.b.domains[d.ID] = d
+	return nil
+}
+
+func (s *backendDomainService) validate(d globodns.Domain) error {
+	if strings.TrimSpace(d.Name) == "" {
+		return unprocessable("name", "can't be blank")
+	}
+
+	for _, other := range s.b.domains {
+		if other.ID != d.ID && normalizeDomainName(other.Name) == normalizeDomainName(d.Name) {
+			return unprocessable("name", "has already been taken")
+		}
+	}
+
+	return nil
+}
+
+func (s *backendDomainService) match(d globodns.Domain, p globodns.ListDomainsParameters) bool {
+	if p.Reverse != nil && *p.Reverse != isReverse(d) {
+		return false
+	}
+
+	if p.View != "" && !strings.EqualFold(p.View, "all") && !strings.EqualFold(s.b.views[d.ViewID], p.View) {
+		return false
+	}
+
+	return matchQuery(p.Query, d.Name)
+}
+
+var _ globodns.RecordService = &backendRecordService{}
+
+type backendRecordService struct {
+	b *Backend
+}
+
+func (s *backendRecordService) Create(ctx context.Context, r globodns.Record) (*globodns.Record, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+
+	if _, found := s.b.domains[r.DomainID]; !found {
+		return nil, notFound()
+	}
+
+	r.Type = strings.ToUpper(r.Type)
+	if err := s.validate(r); err != nil {
+		return nil, err
+	}
+
+	now := s.b.now()
+	s.b.nextRecordID++
+	r.ID = s.b.nextRecordID
+	r.CreatedAt, r.UpdatedAt = &now, &now
+	s.b.records[r.ID] = r
+	return &r, nil
+}
+
+func (s *backendRecordService) Delete(ctx context.Context, recordID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+
+	if _, found := s.b.records[recordID]; !found {
+		return notFound()
+	}
+
+	delete(s.b.records, recordID)
+	return nil
+}
+
+func (s *backendRecordService) Get(ctx context.Context, recordID int) (*globodns.Record, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+
+	r, found := s.b.records[recordID]
+	if !found {
+		return nil, notFound()
+	}
+
+	return &r, nil
+}
+
+func (s *backendRecordService) List(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) ([]globodns.Record, error) {
+	page, err := s.listPage(ctx, domainID, p, p == nil || p.Page == 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Records, nil
+}
+
+func (s *backendRecordService) ListPage(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) (*globodns.RecordPage, error) {
+	return s.listPage(ctx, domainID, p, false)
+}
+
+func (s *backendRecordService) listPage(ctx context.Context, domainID int, p *globodns.ListRecordsParameters, all bool) (*globodns.RecordPage, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var params globodns.ListRecordsParameters
+	if p != nil {
+		params = *p
+	}
+
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+
+	d, found := s.b.domains[domainID]
+	if !found {
+		return nil, notFound()
+	}
+
+	var records []globodns.Record
+	if params.Reverse == nil || *params.Reverse == isReverse(d) {
+		for _, r := range s.b.records {
+			if r.DomainID == domainID && matchQuery(params.Query, r.Name) {
+				records = append(records, r)
+			}
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Name != records[j].Name {
+			return records[i].Name < records[j].Name
+		}
+
+		if records[i].Type != records[j].Type {
+			return records[i].Type < records[j].Type
+		}
+
+		return records[i].ID < records[j].ID
+	})
+
+	perPage := params.PerPage
+	if all {
+		params.Page, perPage = 1, len(records)
+	}
+
+	start, end, pagination := paginate(params.Page, perPage, len(records))
+	return &globodns.RecordPage{Pagination: pagination, Records: records[start:end:end]}, nil
+}
+
+func (s *backendRecordService) Update(ctx context.Context, r globodns.Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+
+	current, found := s.b.records[r.ID]
+	if !found {
+		return notFound()
+	}
+
+	// NOTE: records cannot be moved between domains, and blank fields keep
+	// their current values.
+	r.DomainID = current.DomainID
+	r.CreatedAt = current.CreatedAt
+
+	if r.Name == "" {
+		r.Name = current.Name
+	}
+
+	if r.Type == "" {
+		r.Type = current.Type
+	}
+
+	if r.Content == "" {
+		r.Content = current.Content
+	}
+
+	if r.TTL == nil {
+		r.TTL = current.TTL
+	}
+
+	if r.Prio == nil {
+		r.Prio = current.Prio
+	}
+
+	r.Type = strings.ToUpper(r.Type)
+	if err := s.validate(r); err != nil {
+		return err
+	}
+
+	now := s.b.now()
+	r.UpdatedAt = &now
+	s.b.records[r.ID] = r
+	return nil
+}
+
+func (s *backendRecordService) validate(r globodns.Record) error {
+	switch {
+	case strings.TrimSpace(r.Name) == "":
+		return unprocessable("name", "can't be blank")
+	case r.Type == "":
+		return unprocessable("type", "can't be blank")
+	case strings.TrimSpace(r.Content) == "":
+		return unprocessable("content", "can't be blank")
+	}
+
+	for _, other := range s.b.records {
+		if other.ID == r.ID || other.DomainID != r.DomainID || !strings.EqualFold(other.Name, r.Name) {
+			continue
+		}
+
+		if other.Type == r.Type && other.Content == r.Content {
+			return unprocessable("name", "has already been taken")
+		}
+
+		if other.Type == "CNAME" || r.Type == "CNAME" {
+			return unprocessable("name", "cannot coexist with a CNAME record")
+		}
+	}
+
+	return nil
+}
+
+var _ globodns.BindService = &backendBindService{}
+
+type backendBindService struct {
+	b *Backend
+}
+
+func (s *backendBindService) Export(ctx context.Context) (*globodns.ScheduleExport, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+
+	now := s.b.now()
+	s.b.lastExport = &now
+
+	return &globodns.ScheduleExport{
+		Output:       fmt.Sprintf("BIND export scheduled for %s", now.Format("2006-01-02 15:04:05 -0700")),
+		ScheduleDate: now,
+	}, nil
+}
+
+func (s *backendBindService) ExportNow(ctx context.Context) (*globodns.ImmediateExport, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+
+	now := s.b.now()
+	s.b.lastExport = &now
+
+	var zones []string
+	for _, d := range s.b.domains {
+		zones = append(zones, d.Name)
+	}
+
+	sort.Strings(zones)
+	return &globodns.ImmediateExport{Output: "BIND export finished", Zones: zones}, nil
+}
+
+func (s *backendBindService) LastExport(ctx context.Context) (*globodns.ExportStatus, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+
+	// NOTE: nothing is known before the first export.
+	if s.b.lastExport == nil {
+		return &globodns.ExportStatus{}, nil
+	}
+
+	last := *s.b.lastExport
+	return &globodns.ExportStatus{Status: "ok", Output: "BIND export finished", LastExport: &last, ScheduleDate: &last}, nil
+}
+
+// Wait returns the last export right away, since exports are done as soon as
+// scheduled.
+func (s *backendBindService) Wait(ctx context.Context, se *globodns.ScheduleExport) (*globodns.ExportStatus, error) {
+	if se == nil {
+		return nil, fmt.Errorf("globodns: schedule export cannot be nil")
+	}
+
+	if se.ScheduleDate.IsZero() {
+		return nil, fmt.Errorf("globodns: schedule export has no schedule date")
+	}
+
+	return s.LastExport(ctx)
+}
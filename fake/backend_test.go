@@ -0,0 +1,323 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fake_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/fake"
+)
+
+func newBackend(t *testing.T) *fake.Backend {
+	b := fake.NewBackend()
+	b.Now = func() time.Time { return time.Date(2021, 11, 1, 12, 0, 0, 0, time.UTC) }
+
+	require.NoError(t, b.Seed(fake.State{
+		Views: map[int]string{1: "default", 2: "internal"},
+		Domains: []globodns.Domain{
+			{ID: 10, Name: "example.com", AddressingType: "N", ViewID: 1},
+			{ID: 11, Name: "internal.example.com", AddressingType: "N", ViewID: 2},
+			{ID: 12, Name: "100.196.169.in-addr.arpa", AddressingType: "R", ViewID: 1},
+		},
+		Records: []globodns.Record{
+			{ID: 1, DomainID: 10, Name: "www", Type: "a", Content: "169.196.100.100"},
+			{ID: 2, DomainID: 10, Name: "ftp", Type: "CNAME", Content: "www"},
+		},
+	}))
+
+	return b
+}
+
+func TestBackend_DomainList(t *testing.T) {
+	tests := map[string]struct {
+		parameters    *globodns.ListDomainsParameters
+		expected      []string
+		expectedError string
+	}{
+		"every domain": {
+			expected: []string{"100.196.169.in-addr.arpa", "example.com", "internal.example.com"},
+		},
+
+		"query": {
+			parameters: &globodns.ListDomainsParameters{Query: "example"},
+			expected:   []string{"example.com", "internal.example.com"},
+		},
+
+		"query with wildcard": {
+			parameters: &globodns.ListDomainsParameters{Query: "*.example.com"},
+			expected:   []string{"internal.example.com"},
+		},
+
+		"reverse zones": {
+			parameters: &globodns.ListDomainsParameters{Reverse: globodns.BoolPointer(true)},
+			expected:   []string{"100.196.169.in-addr.arpa"},
+		},
+
+		"view": {
+			parameters: &globodns.ListDomainsParameters{View: "internal"},
+			expected:   []string{"internal.example.com"},
+		},
+
+		"single page": {
+			parameters: &globodns.ListDomainsParameters{Page: 2, PerPage: 2},
+			expected:   []string{"internal.example.com"},
+		},
+
+		"invalid page": {
+			parameters:    &globodns.ListDomainsParameters{Page: -1},
+			expectedError: "globodns: page cannot be negative",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := newBackend(t)
+
+			domains, err := b.Domain.List(context.TODO(), tt.parameters)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+
+			var names []string
+			for _, d := range domains {
+				names = append(names, d.Name)
+			}
+
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+}
+
+func TestBackend_RecordListPage(t *testing.T) {
+	b := newBackend(t)
+
+	for i := 0; i < 30; i++ {
+		_, err := b.Record.Create(context.TODO(), globodns.Record{DomainID: 12, Name: fmt.Sprintf("%d", 100+i), Type: "PTR", Content: "host.example.com."})
+		require.NoError(t, err)
+	}
+
+	page, err := b.Record.ListPage(context.TODO(), 12, nil)
+	require.NoError(t, err)
+	assert.Len(t, page.Records, fake.DefaultPerPage)
+	assert.Equal(t, 1, page.Page)
+	assert.Equal(t, 30, globodns.IntValue(page.Total))
+	assert.Equal(t, 2, globodns.IntValue(page.TotalPages))
+	assert.True(t, page.HasNext())
+
+	page, err = b.Record.ListPage(context.TODO(), 12, &globodns.ListRecordsParameters{Page: 2})
+	require.NoError(t, err)
+	assert.Len(t, page.Records, 5)
+	assert.False(t, page.HasNext())
+
+	page, err = b.Record.ListPage(context.TODO(), 12, &globodns.ListRecordsParameters{Query: "12"})
+	require.NoError(t, err)
+	assert.Len(t, page.Records, 11)
+
+	records, err := b.Record.List(context.TODO(), 12, nil)
+	require.NoError(t, err)
+	assert.Len(t, records, 30)
+
+	records, err = b.Record.List(context.TODO(), 12, &globodns.ListRecordsParameters{Reverse: globodns.BoolPointer(false)})
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	_, err = b.Record.List(context.TODO(), 666, nil)
+	assert.EqualError(t, err, `globodns: unexpected HTTP status code: Code: 404 Body: {"error":"NOT FOUND"}`)
+}
+
+func TestBackend_RecordCreate(t *testing.T) {
+	tests := map[string]struct {
+		record        globodns.Record
+		expected      *globodns.Record
+		expectedError string
+	}{
+		"unknown domain": {
+			record:        globodns.Record{DomainID: 666, Name: "www", Type: "A", Content: "169.196.100.100"},
+			expectedError: `globodns: unexpected HTTP status code: Code: 404 Body: {"error":"NOT FOUND"}`,
+		},
+
+		"blank content": {
+			record:        globodns.Record{DomainID: 10, Name: "www", Type: "A"},
+			expectedError: `globodns: unexpected HTTP status code: Code: 422 Body: {"errors":{"content":["can't be blank"]}}`,
+		},
+
+		"duplicated record": {
+			record:        globodns.Record{DomainID: 10, Name: "WWW", Type: "A", Content: "169.196.100.100"},
+			expectedError: `globodns: unexpected HTTP status code: Code: 422 Body: {"errors":{"name":["has already been taken"]}}`,
+		},
+
+		"name taken by a CNAME": {
+			record:        globodns.Record{DomainID: 10, Name: "ftp", Type: "A", Content: "169.196.100.101"},
+			expectedError: `globodns: unexpected HTTP status code: Code: 422 Body: {"errors":{"name":["cannot coexist with a CNAME record"]}}`,
+		},
+
+		"creating a record": {
+			record: globodns.Record{DomainID: 10, Name: "www", Type: "a", Content: "169.196.100.101"},
+			expected: &globodns.Record{
+				ID:        3,
+				DomainID:  10,
+				Name:      "www",
+				Type:      "A",
+				Content:   "169.196.100.101",
+				CreatedAt: globodns.TimePointer(time.Date(2021, 11, 1, 12, 0, 0, 0, time.UTC)),
+				UpdatedAt: globodns.TimePointer(time.Date(2021, 11, 1, 12, 0, 0, 0, time.UTC)),
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := newBackend(t)
+
+			got, err := b.Record.Create(context.TODO(), tt.record)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+
+			stored, err := b.Record.Get(context.TODO(), got.ID)
+			require.NoError(t, err)
+			assert.Equal(t, got, stored)
+		})
+	}
+}
+
+func TestBackend_Mutations(t *testing.T) {
+	b := newBackend(t)
+	ctx := context.TODO()
+
+	err := b.Record.Update(ctx, globodns.Record{ID: 1, Content: "169.196.100.200", TTL: globodns.StringPointer("60")})
+	require.NoError(t, err)
+
+	r, err := b.Record.Get(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "www", r.Name)
+	assert.Equal(t, "169.196.100.200", r.Content)
+	assert.Equal(t, "60", globodns.StringValue(r.TTL))
+
+	err = b.Record.Update(ctx, globodns.Record{ID: 1, Name: "ftp"})
+	assert.EqualError(t, err, `globodns: unexpected HTTP status code: Code: 422 Body: {"errors":{"name":["cannot coexist with a CNAME record"]}}`)
+
+	err = b.Record.Delete(ctx, 2)
+	require.NoError(t, err)
+
+	err = b.Record.Delete(ctx, 2)
+	assert.EqualError(t, err, `globodns: unexpected HTTP status code: Code: 404 Body: {"error":"NOT FOUND"}`)
+
+	_, err = b.Domain.Create(ctx, globodns.Domain{Name: "Example.com."})
+	assert.EqualError(t, err, `globodns: unexpected HTTP status code: Code: 422 Body: {"errors":{"name":["has already been taken"]}}`)
+
+	d, err := b.Domain.Create(ctx, globodns.Domain{Name: "example.org"})
+	require.NoError(t, err)
+	assert.Equal(t, 13, d.ID)
+
+	err = b.Domain.Update(ctx, globodns.Domain{ID: 13, TTL: globodns.StringPointer("3600")})
+	require.NoError(t, err)
+
+	d, err = b.Domain.Get(ctx, 13)
+	require.NoError(t, err)
+	assert.Equal(t, &globodns.Domain{ID: 13, Name: "example.org", TTL: globodns.StringPointer("3600")}, d)
+
+	err = b.Domain.Delete(ctx, 10)
+	require.NoError(t, err)
+
+	_, err = b.Record.Get(ctx, 1)
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err = b.Domain.Get(ctx, 13)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestBackend_Recorder(t *testing.T) {
+	b := newBackend(t)
+	ctx := context.TODO()
+	client := b.Client()
+
+	_, err := client.Record.Create(ctx, globodns.Record{DomainID: 10, Name: "api", Type: "A", Content: "169.196.100.101"})
+	require.NoError(t, err)
+
+	err = client.Record.Delete(ctx, 666)
+	assert.Error(t, err)
+
+	_, err = client.Bind.Export(ctx)
+	require.NoError(t, err)
+
+	assert.Same(t, b.Recorder, fake.RecorderOf(client))
+	b.Recorder.AssertCalledWith(t, "Record.Create", 1, fake.MatchRecord(globodns.Record{Name: "api", Type: "A"}))
+	b.Recorder.AssertOrder(t, "Record.Create", "Record.Delete", "Bind.Export")
+
+	calls := b.Recorder.Calls("Record.Delete")
+	require.Len(t, calls, 1)
+	assert.EqualError(t, calls[0].Err, `globodns: unexpected HTTP status code: Code: 404 Body: {"error":"NOT FOUND"}`)
+}
+
+func TestBackend_BindWait(t *testing.T) {
+	b := newBackend(t)
+	ctx := context.TODO()
+
+	es, err := b.Bind.LastExport(ctx)
+	require.NoError(t, err)
+	assert.False(t, es.Succeeded(), "nothing is known before the first export")
+
+	se, err := b.Bind.Export(ctx)
+	require.NoError(t, err)
+
+	b.Recorder.Reset()
+
+	es, err = b.Bind.Wait(ctx, se)
+	require.NoError(t, err)
+	assert.True(t, es.Succeeded())
+	assert.Equal(t, se.ScheduleDate, *es.LastExport)
+	b.Recorder.AssertCount(t, "Bind.ExportNow", 0)
+	b.Recorder.AssertCount(t, "Bind.Export", 0)
+
+	_, err = b.Bind.Wait(ctx, &globodns.ScheduleExport{})
+	assert.EqualError(t, err, "globodns: schedule export has no schedule date")
+}
+
+func TestBackend_SnapshotAndReset(t *testing.T) {
+	b := newBackend(t)
+
+	snapshot := b.Snapshot()
+	assert.Len(t, snapshot.Domains, 3)
+	assert.Equal(t, []globodns.Record{
+		{ID: 1, DomainID: 10, Name: "www", Type: "A", Content: "169.196.100.100"},
+		{ID: 2, DomainID: 10, Name: "ftp", Type: "CNAME", Content: "www"},
+	}, snapshot.Records)
+
+	assert.EqualError(t, b.Seed(fake.State{Domains: []globodns.Domain{{ID: 10, Name: "other.com"}}}), "fake: domain 10 already exists")
+	assert.EqualError(t, b.Seed(fake.State{Records: []globodns.Record{{DomainID: 666, Name: "www"}}}), "fake: domain 666 of record www not found")
+
+	client := b.Client()
+	_, err := client.Record.Create(context.TODO(), globodns.Record{DomainID: 10, Name: "api", Type: "A", Content: "169.196.100.101"})
+	require.NoError(t, err)
+	assert.Len(t, b.Snapshot().Records, 3)
+
+	b.Reset()
+	assert.Equal(t, fake.State{Views: map[int]string{}}, b.Snapshot())
+
+	require.NoError(t, b.Seed(snapshot))
+	assert.Equal(t, snapshot, b.Snapshot())
+
+	d, err := b.Domain.Create(context.TODO(), globodns.Domain{Name: "example.org"})
+	require.NoError(t, err)
+	assert.Equal(t, 13, d.ID)
+}
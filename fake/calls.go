@@ -0,0 +1,276 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fake
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+)
+
+// Call is a single call made to a fake service.
+type Call struct {
+	// Method is the service and method names, e.g. "Record.Create".
+	Method string
+	// Context is the context the method was called with.
+	Context context.Context
+	// Args holds the arguments following the context.
+	Args []interface{}
+	// Result is the non-error value returned, nil for methods returning only
+	// an error.
+	Result interface{}
+	Err    error
+	// Seq is the position of the call in its recorder, starting at 1.
+	Seq int
+}
+
+func (c Call) String() string {
+	args := make([]string, 0, len(c.Args))
+	for _, a := range c.Args {
+		args = append(args, formatValue(a))
+	}
+
+	return fmt.Sprintf("#%d %s(%s)", c.Seq, c.Method, strings.Join(args, ", "))
+}
+
+// TestingT is the subset of *testing.T used by the assertion helpers.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+type tHelper interface {
+	Helper()
+}
+
+// Matcher matches a single call argument. Matchers may be passed instead of
+// values to CalledWith and AssertCalledWith.
+type Matcher func(arg interface{}) bool
+
+// Any matches any argument.
+func Any() Matcher {
+	return func(interface{}) bool { return true }
+}
+
+// MatchRecord matches a record argument having the non-zero fields of want,
+// e.g. MatchRecord(globodns.Record{Type: "A", Name: "www"}).
+func MatchRecord(want globodns.Record) Matcher {
+	return func(arg interface{}) bool {
+		r, ok := arg.(globodns.Record)
+		return ok && matchFields(want, r)
+	}
+}
+
+// MatchDomain matches a domain argument having the non-zero fields of want.
+func MatchDomain(want globodns.Domain) Matcher {
+	return func(arg interface{}) bool {
+		d, ok := arg.(globodns.Domain)
+		return ok && matchFields(want, d)
+	}
+}
+
+func matchFields(want, got interface{}) bool {
+	wv, gv := reflect.ValueOf(want), reflect.ValueOf(got)
+	for i := 0; i < wv.NumField(); i++ {
+		if wv.Field(i).IsZero() {
+			continue
+		}
+
+		if !reflect.DeepEqual(wv.Field(i).Interface(), gv.Field(i).Interface()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Recorder keeps the calls made to fake services. It's safe for concurrent
+// use.
+type Recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+func (r *Recorder) record(ctx context.Context, method string, args []interface{}, result interface{}, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if v := reflect.ValueOf(result); result != nil && v.Kind() == reflect.Ptr && v.IsNil() {
+		result = nil
+	}
+
+	r.calls = append(r.calls, Call{
+		Method:  method,
+		Context: ctx,
+		Args:    args,
+		Result:  result,
+		Err:     err,
+		Seq:     len(r.calls) + 1,
+	})
+}
+
+// Calls returns the calls made to the given methods, or all calls if none is
+// given, in the order they were made.
+func (r *Recorder) Calls(methods ...string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var calls []Call
+	for _, c := range r.calls {
+		if len(methods) == 0 || contains(methods, c.Method) {
+			calls = append(calls, c)
+		}
+	}
+
+	return calls
+}
+
+// Count returns how many times method was called.
+func (r *Recorder) Count(method string) int {
+	return len(r.Calls(method))
+}
+
+// CalledWith returns the calls to method whose arguments match args.
+func (r *Recorder) CalledWith(method string, args ...interface{}) []Call {
+	var calls []Call
+	for _, c := range r.Calls(method) {
+		if matchArgs(args, c.Args) {
+			calls = append(calls, c)
+		}
+	}
+
+	return calls
+}
+
+// Reset forgets every call made so far.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = nil
+}
+
+// AssertCount asserts that method was called exactly n times.
+func (r *Recorder) AssertCount(t TestingT, method string, n int) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	if got := r.Count(method); got != n {
+		t.Errorf("fake: expected %s to be called %d time(s), got %d\n%s", method, n, got, r.dump())
+		return false
+	}
+
+	return true
+}
+
+// AssertCalledWith asserts that method was called exactly n times with
+// arguments matching args.
+func (r *Recorder) AssertCalledWith(t TestingT, method string, n int, args ...interface{}) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	if got := len(r.CalledWith(method, args...)); got != n {
+		t.Errorf("fake: expected %s to be called %d time(s) with %s, got %d\n%s", method, n, formatArgs(args), got, r.dump())
+		return false
+	}
+
+	return true
+}
+
+// AssertOrder asserts that the given methods were called in that order, not
+// necessarily one right after the other.
+func (r *Recorder) AssertOrder(t TestingT, methods ...string) bool {
+	if h, ok := t.(tHelper); ok {
+		h.Helper()
+	}
+
+	i := 0
+	for _, c := range r.Calls() {
+		if i < len(methods) && c.Method == methods[i] {
+			i++
+		}
+	}
+
+	if i < len(methods) {
+		t.Errorf("fake: expected calls in order %s, missing %s\n%s", strings.Join(methods, ", "), methods[i], r.dump())
+		return false
+	}
+
+	return true
+}
+
+func (r *Recorder) dump() string {
+	calls := r.Calls()
+	if len(calls) == 0 {
+		return "no calls recorded"
+	}
+
+	lines := make([]string, 0, len(calls)+1)
+	lines = append(lines, "recorded calls:")
+	for _, c := range calls {
+		lines = append(lines, "  "+c.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func matchArgs(want, got []interface{}) bool {
+	if len(want) > len(got) {
+		return false
+	}
+
+	for i, w := range want {
+		if m, ok := w.(Matcher); ok {
+			if !m(got[i]) {
+				return false
+			}
+
+			continue
+		}
+
+		if !reflect.DeepEqual(w, got[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func formatArgs(args []interface{}) string {
+	s := make([]string, 0, len(args))
+	for _, a := range args {
+		if _, ok := a.(Matcher); ok {
+			s = append(s, "<matcher>")
+			continue
+		}
+
+		s = append(s, formatValue(a))
+	}
+
+	return "(" + strings.Join(s, ", ") + ")"
+}
+
+func formatValue(v interface{}) string {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		return fmt.Sprintf("&%+v", rv.Elem().Interface())
+	}
+
+	return fmt.Sprintf("%+v", v)
+}
+
+func contains(ss []string, s string) bool {
+	for _, item := range ss {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
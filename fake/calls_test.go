@@ -0,0 +1,106 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fake_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/fake"
+)
+
+type contextKey string
+
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRecorder(t *testing.T) {
+	client := fake.New()
+	client.Record.(*fake.FakeRecordService).FakeCreate = func(ctx context.Context, r globodns.Record) (*globodns.Record, error) {
+		r.ID = 1
+		return &r, nil
+	}
+	client.Record.(*fake.FakeRecordService).FakeDelete = func(ctx context.Context, recordID int) error {
+		return fmt.Errorf("cannot delete record %d", recordID)
+	}
+
+	ctx := context.WithValue(context.TODO(), contextKey("request-id"), "abc")
+
+	_, err := client.Domain.Get(ctx, 10)
+	require.Error(t, err)
+
+	_, err = client.Record.Create(ctx, globodns.Record{DomainID: 10, Name: "www", Type: "A", Content: "169.196.100.100"})
+	require.NoError(t, err)
+
+	err = client.Record.Delete(ctx, 1)
+	require.EqualError(t, err, "cannot delete record 1")
+
+	rec := fake.RecorderOf(client)
+	require.NotNil(t, rec)
+
+	calls := rec.Calls("Record.Create")
+	require.Len(t, calls, 1)
+	assert.Equal(t, 2, calls[0].Seq)
+	assert.Equal(t, "abc", calls[0].Context.Value(contextKey("request-id")))
+	assert.Equal(t, []interface{}{globodns.Record{DomainID: 10, Name: "www", Type: "A", Content: "169.196.100.100"}}, calls[0].Args)
+	assert.Equal(t, &globodns.Record{ID: 1, DomainID: 10, Name: "www", Type: "A", Content: "169.196.100.100"}, calls[0].Result)
+	assert.NoError(t, calls[0].Err)
+
+	calls = rec.Calls("Domain.Get", "Record.Delete")
+	require.Len(t, calls, 2)
+	assert.Nil(t, calls[0].Result)
+	assert.EqualError(t, calls[0].Err, "fake does not implement this method")
+	assert.EqualError(t, calls[1].Err, "cannot delete record 1")
+
+	assert.True(t, rec.AssertCount(t, "Record.Create", 1))
+	assert.True(t, rec.AssertCalledWith(t, "Record.Create", 1, fake.MatchRecord(globodns.Record{Type: "A", Name: "www"})))
+	assert.True(t, rec.AssertCalledWith(t, "Record.Delete", 1, 1))
+	assert.True(t, rec.AssertOrder(t, "Domain.Get", "Record.Delete"))
+
+	mock := &recordingT{}
+	assert.False(t, rec.AssertCount(mock, "Record.Update", 1))
+	assert.False(t, rec.AssertCalledWith(mock, "Record.Create", 1, fake.MatchRecord(globodns.Record{Type: "AAAA"})))
+	assert.False(t, rec.AssertOrder(mock, "Record.Delete", "Record.Create"))
+	require.Len(t, mock.errors, 3)
+	assert.Contains(t, mock.errors[0], "fake: expected Record.Update to be called 1 time(s), got 0")
+	assert.Contains(t, mock.errors[0], "#2 Record.Create({Content:169.196.100.100 Name:www Type:A")
+	assert.Contains(t, mock.errors[1], "fake: expected Record.Create to be called 1 time(s) with (<matcher>), got 0")
+	assert.Contains(t, mock.errors[2], "fake: expected calls in order Record.Delete, Record.Create, missing Record.Create")
+
+	rec.Reset()
+	assert.Empty(t, rec.Calls())
+}
+
+func TestRecorder_Concurrent(t *testing.T) {
+	f := &fake.FakeRecordService{
+		FakeUpdate: func(ctx context.Context, r globodns.Record) error { return nil },
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			f.Update(context.TODO(), globodns.Record{ID: i})
+		}(i)
+	}
+
+	wg.Wait()
+
+	f.Recorder.AssertCount(t, "Record.Update", 50)
+	f.Recorder.AssertCalledWith(t, "Record.Update", 1, fake.MatchRecord(globodns.Record{ID: 42}))
+	f.Recorder.AssertCalledWith(t, "Record.Update", 50, fake.Any())
+}
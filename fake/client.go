@@ -7,39 +7,205 @@ package fake
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	globodns "github.com/tsuru/go-globodnsclient"
 )
 
+// New returns a client made of fake services sharing the same Recorder, so
+// the order of calls across services is kept.
 func New() *globodns.Client {
+	r := &Recorder{}
+
 	return &globodns.Client{
-		Bind:   &FakeBindService{},
-		Domain: &FakeDomainService{},
-		Record: &FakeRecordService{},
+		Bind:   &FakeBindService{Recorder: r},
+		Domain: &FakeDomainService{Recorder: r},
+		Record: &FakeRecordService{Recorder: r},
+	}
+}
+
+// RecorderOf returns the recorder of the fake services of c, nil if there
+// are none.
+func RecorderOf(c *globodns.Client) *Recorder {
+	if f, ok := c.Record.(*FakeRecordService); ok {
+		return f.recorder()
+	}
+
+	if f, ok := c.Domain.(*FakeDomainService); ok {
+		return f.recorder()
+	}
+
+	if f, ok := c.Bind.(*FakeBindService); ok {
+		return f.recorder()
 	}
+
+	return nil
+}
+
+// recordBind returns a fake recording in r the calls to next.
+func recordBind(next globodns.BindService, r *Recorder) *FakeBindService {
+	return &FakeBindService{
+		FakeExport:     next.Export,
+		FakeExportNow:  next.ExportNow,
+		FakeLastExport: next.LastExport,
+		FakeWait:       next.Wait,
+		Recorder:       r,
+	}
+}
+
+// recordDomain returns a fake recording in r the calls to next.
+func recordDomain(next globodns.DomainService, r *Recorder) *FakeDomainService {
+	return &FakeDomainService{
+		FakeCreate:   next.Create,
+		FakeDelete:   next.Delete,
+		FakeGet:      next.Get,
+		FakeList:     next.List,
+		FakeListPage: next.ListPage,
+		FakeUpdate:   next.Update,
+		Recorder:     r,
+	}
+}
+
+// recordRecord returns a fake recording in r the calls to next.
+func recordRecord(next globodns.RecordService, r *Recorder) *FakeRecordService {
+	return &FakeRecordService{
+		FakeCreate:   next.Create,
+		FakeDelete:   next.Delete,
+		FakeGet:      next.Get,
+		FakeList:     next.List,
+		FakeListPage: next.ListPage,
+		FakeUpdate:   next.Update,
+		Recorder:     r,
+	}
+}
+
+// recorderHolder lazily creates the recorder of fakes built without one.
+type recorderHolder struct {
+	mu sync.Mutex
+}
+
+func (h *recorderHolder) get(r **Recorder) *Recorder {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if *r == nil {
+		*r = &Recorder{}
+	}
+
+	return *r
 }
 
 var _ globodns.BindService = &FakeBindService{}
 
 type FakeBindService struct {
-	FakeExport func(ctx context.Context) (*globodns.ScheduleExport, error)
+	FakeExport     func(ctx context.Context) (*globodns.ScheduleExport, error)
+	FakeExportNow  func(ctx context.Context) (*globodns.ImmediateExport, error)
+	FakeLastExport func(ctx context.Context) (*globodns.ExportStatus, error)
+	FakeWait       func(ctx context.Context, se *globodns.ScheduleExport) (*globodns.ExportStatus, error)
+
+	// Recorder records every call, created on first use if nil.
+	Recorder *Recorder
+
+	holder recorderHolder
 }
 
-func (f *FakeBindService) Export(ctx context.Context) (*globodns.ScheduleExport, error) {
-	if f.FakeExport != nil {
-		return f.FakeExport(ctx)
+func (f *FakeBindService) recorder() *Recorder {
+	return f.holder.get(&f.Recorder)
+}
+
+func (f *FakeBindService) Export(ctx context.Context) (se *globodns.ScheduleExport, err error) {
+	defer func() { f.recorder().record(ctx, "Bind.Export", nil, se, err) }()
+
+	if f.FakeExport == nil {
+		return nil, fmt.Errorf("fake does not implement this method")
+	}
+
+	return f.FakeExport(ctx)
+}
+
+func (f *FakeBindService) ExportNow(ctx context.Context) (ie *globodns.ImmediateExport, err error) {
+	defer func() { f.recorder().record(ctx, "Bind.ExportNow", nil, ie, err) }()
+
+	if f.FakeExportNow == nil {
+		return nil, fmt.Errorf("fake does not implement this method")
 	}
 
-	return nil, nil
+	return f.FakeExportNow(ctx)
+}
+
+func (f *FakeBindService) LastExport(ctx context.Context) (es *globodns.ExportStatus, err error) {
+	defer func() { f.recorder().record(ctx, "Bind.LastExport", nil, es, err) }()
+
+	if f.FakeLastExport == nil {
+		return nil, fmt.Errorf("fake does not implement this method")
+	}
+
+	return f.FakeLastExport(ctx)
+}
+
+func (f *FakeBindService) Wait(ctx context.Context, se *globodns.ScheduleExport) (es *globodns.ExportStatus, err error) {
+	defer func() { f.recorder().record(ctx, "Bind.Wait", []interface{}{se}, es, err) }()
+
+	if f.FakeWait == nil {
+		return nil, fmt.Errorf("fake does not implement this method")
+	}
+
+	return f.FakeWait(ctx, se)
 }
 
 var _ globodns.DomainService = &FakeDomainService{}
 
 type FakeDomainService struct {
-	FakeList func(ctx context.Context, p *globodns.ListDomainsParameters) ([]globodns.Domain, error)
+	FakeCreate   func(ctx context.Context, d globodns.Domain) (*globodns.Domain, error)
+	FakeDelete   func(ctx context.Context, domainID int) error
+	FakeGet      func(ctx context.Context, domainID int) (*globodns.Domain, error)
+	FakeList     func(ctx context.Context, p *globodns.ListDomainsParameters) ([]globodns.Domain, error)
+	FakeListPage func(ctx context.Context, p *globodns.ListDomainsParameters) (*globodns.DomainPage, error)
+	FakeUpdate   func(ctx context.Context, d globodns.Domain) error
+
+	// Recorder records every call, created on first use if nil.
+	Recorder *Recorder
+
+	holder recorderHolder
 }
 
-func (f *FakeDomainService) List(ctx context.Context, p *globodns.ListDomainsParameters) ([]globodns.Domain, error) {
+func (f *FakeDomainService) recorder() *Recorder {
+	return f.holder.get(&f.Recorder)
+}
+
+func (f *FakeDomainService) Create(ctx context.Context, d globodns.Domain) (created *globodns.Domain, err error) {
+	defer func() { f.recorder().record(ctx, "Domain.Create", []interface{}{d}, created, err) }()
+
+	if f.FakeCreate == nil {
+		return nil, fmt.Errorf("fake does not implement this method")
+	}
+
+	return f.FakeCreate(ctx, d)
+}
+
+func (f *FakeDomainService) Delete(ctx context.Context, domainID int) (err error) {
+	defer func() { f.recorder().record(ctx, "Domain.Delete", []interface{}{domainID}, nil, err) }()
+
+	if f.FakeDelete == nil {
+		return fmt.Errorf("fake does not implement this method")
+	}
+
+	return f.FakeDelete(ctx, domainID)
+}
+
+func (f *FakeDomainService) Get(ctx context.Context, domainID int) (d *globodns.Domain, err error) {
+	defer func() { f.recorder().record(ctx, "Domain.Get", []interface{}{domainID}, d, err) }()
+
+	if f.FakeGet == nil {
+		return nil, fmt.Errorf("fake does not implement this method")
+	}
+
+	return f.FakeGet(ctx, domainID)
+}
+
+func (f *FakeDomainService) List(ctx context.Context, p *globodns.ListDomainsParameters) (ds []globodns.Domain, err error) {
+	defer func() { f.recorder().record(ctx, "Domain.List", []interface{}{p}, ds, err) }()
+
 	if f.FakeList == nil {
 		return nil, fmt.Errorf("fake does not implement this method")
 	}
@@ -47,16 +213,49 @@ func (f *FakeDomainService) List(ctx context.Context, p *globodns.ListDomainsPar
 	return f.FakeList(ctx, p)
 }
 
+func (f *FakeDomainService) ListPage(ctx context.Context, p *globodns.ListDomainsParameters) (page *globodns.DomainPage, err error) {
+	defer func() { f.recorder().record(ctx, "Domain.ListPage", []interface{}{p}, page, err) }()
+
+	if f.FakeListPage == nil {
+		return nil, fmt.Errorf("fake does not implement this method")
+	}
+
+	return f.FakeListPage(ctx, p)
+}
+
+func (f *FakeDomainService) Update(ctx context.Context, d globodns.Domain) (err error) {
+	defer func() { f.recorder().record(ctx, "Domain.Update", []interface{}{d}, nil, err) }()
+
+	if f.FakeUpdate == nil {
+		return fmt.Errorf("fake does not implement this method")
+	}
+
+	return f.FakeUpdate(ctx, d)
+}
+
 var _ globodns.RecordService = &FakeRecordService{}
 
 type FakeRecordService struct {
-	FakeCreate func(ctx context.Context, r globodns.Record) (*globodns.Record, error)
-	FakeDelete func(ctx context.Context, recordID int) error
-	FakeList   func(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) ([]globodns.Record, error)
-	FakeUpdate func(ctx context.Context, r globodns.Record) error
+	FakeCreate   func(ctx context.Context, r globodns.Record) (*globodns.Record, error)
+	FakeDelete   func(ctx context.Context, recordID int) error
+	FakeGet      func(ctx context.Context, recordID int) (*globodns.Record, error)
+	FakeList     func(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) ([]globodns.Record, error)
+	FakeListPage func(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) (*globodns.RecordPage, error)
+	FakeUpdate   func(ctx context.Context, r globodns.Record) error
+
+	// Recorder records every call, created on first use if nil.
+	Recorder *Recorder
+
+	holder recorderHolder
+}
+
+func (f *FakeRecordService) recorder() *Recorder {
+	return f.holder.get(&f.Recorder)
 }
 
-func (f *FakeRecordService) Create(ctx context.Context, r globodns.Record) (*globodns.Record, error) {
+func (f *FakeRecordService) Create(ctx context.Context, r globodns.Record) (created *globodns.Record, err error) {
+	defer func() { f.recorder().record(ctx, "Record.Create", []interface{}{r}, created, err) }()
+
 	if f.FakeCreate == nil {
 		return nil, fmt.Errorf("fake does not implement this method")
 	}
@@ -64,7 +263,9 @@ func (f *FakeRecordService) Create(ctx context.Context, r globodns.Record) (*glo
 	return f.FakeCreate(ctx, r)
 }
 
-func (f *FakeRecordService) Delete(ctx context.Context, recordID int) error {
+func (f *FakeRecordService) Delete(ctx context.Context, recordID int) (err error) {
+	defer func() { f.recorder().record(ctx, "Record.Delete", []interface{}{recordID}, nil, err) }()
+
 	if f.FakeDelete == nil {
 		return fmt.Errorf("fake does not implement this method")
 	}
@@ -72,7 +273,19 @@ func (f *FakeRecordService) Delete(ctx context.Context, recordID int) error {
 	return f.FakeDelete(ctx, recordID)
 }
 
-func (f *FakeRecordService) List(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) ([]globodns.Record, error) {
+func (f *FakeRecordService) Get(ctx context.Context, recordID int) (r *globodns.Record, err error) {
+	defer func() { f.recorder().record(ctx, "Record.Get", []interface{}{recordID}, r, err) }()
+
+	if f.FakeGet == nil {
+		return nil, fmt.Errorf("fake does not implement this method")
+	}
+
+	return f.FakeGet(ctx, recordID)
+}
+
+func (f *FakeRecordService) List(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) (rs []globodns.Record, err error) {
+	defer func() { f.recorder().record(ctx, "Record.List", []interface{}{domainID, p}, rs, err) }()
+
 	if f.FakeList == nil {
 		return nil, fmt.Errorf("fake does not implement this method")
 	}
@@ -80,7 +293,19 @@ func (f *FakeRecordService) List(ctx context.Context, domainID int, p *globodns.
 	return f.FakeList(ctx, domainID, p)
 }
 
-func (f *FakeRecordService) Update(ctx context.Context, r globodns.Record) error {
+func (f *FakeRecordService) ListPage(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) (page *globodns.RecordPage, err error) {
+	defer func() { f.recorder().record(ctx, "Record.ListPage", []interface{}{domainID, p}, page, err) }()
+
+	if f.FakeListPage == nil {
+		return nil, fmt.Errorf("fake does not implement this method")
+	}
+
+	return f.FakeListPage(ctx, domainID, p)
+}
+
+func (f *FakeRecordService) Update(ctx context.Context, r globodns.Record) (err error) {
+	defer func() { f.recorder().record(ctx, "Record.Update", []interface{}{r}, nil, err) }()
+
 	if f.FakeUpdate == nil {
 		return fmt.Errorf("fake does not implement this method")
 	}
@@ -0,0 +1,46 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fake_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/fake"
+)
+
+func TestNew_UnimplementedMethods(t *testing.T) {
+	client := fake.New()
+	ctx := context.TODO()
+
+	calls := map[string]func() error{
+		"Bind.Export":     func() error { _, err := client.Bind.Export(ctx); return err },
+		"Bind.ExportNow":  func() error { _, err := client.Bind.ExportNow(ctx); return err },
+		"Bind.LastExport": func() error { _, err := client.Bind.LastExport(ctx); return err },
+		"Bind.Wait":       func() error { _, err := client.Bind.Wait(ctx, nil); return err },
+		"Domain.Create":   func() error { _, err := client.Domain.Create(ctx, globodns.Domain{}); return err },
+		"Domain.Delete":   func() error { return client.Domain.Delete(ctx, 1) },
+		"Domain.Get":      func() error { _, err := client.Domain.Get(ctx, 1); return err },
+		"Domain.List":     func() error { _, err := client.Domain.List(ctx, nil); return err },
+		"Domain.ListPage": func() error { _, err := client.Domain.ListPage(ctx, nil); return err },
+		"Domain.Update":   func() error { return client.Domain.Update(ctx, globodns.Domain{}) },
+		"Record.Create":   func() error { _, err := client.Record.Create(ctx, globodns.Record{}); return err },
+		"Record.Delete":   func() error { return client.Record.Delete(ctx, 1) },
+		"Record.Get":      func() error { _, err := client.Record.Get(ctx, 1); return err },
+		"Record.List":     func() error { _, err := client.Record.List(ctx, 1, nil); return err },
+		"Record.ListPage": func() error { _, err := client.Record.ListPage(ctx, 1, nil); return err },
+		"Record.Update":   func() error { return client.Record.Update(ctx, globodns.Record{}) },
+	}
+
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			assert.EqualError(t, call(), "fake does not implement this method")
+			fake.RecorderOf(client).AssertCount(t, name, 1)
+		})
+	}
+}
@@ -0,0 +1,385 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package conformance holds behavioral tests that every implementation of the
+// GloboDNS services should pass, so fakes can be checked against the real
+// client.
+//
+//	func TestBackend(t *testing.T) {
+//		conformance.Run(t, func(t *testing.T) *globodns.Client {
+//			return fake.NewBackend().Client()
+//		})
+//	}
+package conformance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+)
+
+// Factory returns a client whose services talk to a fresh GloboDNS with no
+// domains nor records.
+type Factory func(t *testing.T) *globodns.Client
+
+// Run runs every scenario against the clients returned by newClient.
+func Run(t *testing.T, newClient Factory) {
+	t.Run("Domain", func(t *testing.T) { TestDomainService(t, newClient) })
+	t.Run("Record", func(t *testing.T) { TestRecordService(t, newClient) })
+	t.Run("Bind", func(t *testing.T) { TestBindService(t, newClient) })
+}
+
+// TestDomainService runs the scenarios covering DomainService.
+func TestDomainService(t *testing.T, newClient Factory) {
+	ctx := context.TODO()
+
+	t.Run("create and get", func(t *testing.T) {
+		c := newClient(t)
+
+		d, err := c.Domain.Create(ctx, globodns.Domain{Name: "example.com", TTL: globodns.StringPointer("3600")})
+		require.NoError(t, err)
+		require.NotNil(t, d)
+		assert.NotZero(t, d.ID)
+		assert.Equal(t, "example.com", d.Name)
+
+		got, err := c.Domain.Get(ctx, d.ID)
+		require.NoError(t, err)
+		assert.Equal(t, d.ID, got.ID)
+		assert.Equal(t, "example.com", got.Name)
+		assert.Equal(t, "3600", globodns.StringValue(got.TTL))
+	})
+
+	t.Run("create rejects invalid domains", func(t *testing.T) {
+		c := newClient(t)
+		mustCreateDomain(t, c, "example.com")
+
+		_, err := c.Domain.Create(ctx, globodns.Domain{})
+		assert.Error(t, err)
+
+		_, err = c.Domain.Create(ctx, globodns.Domain{Name: "example.com"})
+		assertStatusCode(t, err, 422)
+	})
+
+	t.Run("get unknown domain", func(t *testing.T) {
+		c := newClient(t)
+
+		_, err := c.Domain.Get(ctx, 666)
+		assertStatusCode(t, err, 404)
+
+		_, err = c.Domain.Get(ctx, -1)
+		assert.Error(t, err)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		c := newClient(t)
+		for _, name := range []string{"example.com", "example.org", "tsuru.io"} {
+			mustCreateDomain(t, c, name)
+		}
+
+		domains, err := c.Domain.List(ctx, nil)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"example.com", "example.org", "tsuru.io"}, domainNames(domains))
+
+		domains, err = c.Domain.List(ctx, &globodns.ListDomainsParameters{Query: "example"})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"example.com", "example.org"}, domainNames(domains))
+
+		domains, err = c.Domain.List(ctx, &globodns.ListDomainsParameters{Query: "unknown"})
+		require.NoError(t, err)
+		assert.Empty(t, domains)
+	})
+
+	t.Run("list page", func(t *testing.T) {
+		c := newClient(t)
+		for _, name := range []string{"example.com", "example.org", "tsuru.io"} {
+			mustCreateDomain(t, c, name)
+		}
+
+		page, err := c.Domain.ListPage(ctx, &globodns.ListDomainsParameters{Page: 1, PerPage: 2})
+		require.NoError(t, err)
+		assert.Len(t, page.Domains, 2)
+		assert.Equal(t, 3, globodns.IntValue(page.Total))
+		assert.True(t, page.HasNext())
+
+		page, err = c.Domain.ListPage(ctx, &globodns.ListDomainsParameters{Page: 2, PerPage: 2})
+		require.NoError(t, err)
+		assert.Len(t, page.Domains, 1)
+		assert.False(t, page.HasNext())
+	})
+
+	t.Run("update", func(t *testing.T) {
+		c := newClient(t)
+		d := mustCreateDomain(t, c, "example.com")
+
+		require.NoError(t, c.Domain.Update(ctx, globodns.Domain{ID: d.ID, Name: "example.net"}))
+
+		got, err := c.Domain.Get(ctx, d.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "example.net", got.Name)
+
+		err = c.Domain.Update(ctx, globodns.Domain{ID: 666, Name: "example.org"})
+		assertStatusCode(t, err, 404)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		c := newClient(t)
+		d := mustCreateDomain(t, c, "example.com")
+
+		require.NoError(t, c.Domain.Delete(ctx, d.ID))
+
+		_, err := c.Domain.Get(ctx, d.ID)
+		assertStatusCode(t, err, 404)
+
+		assertStatusCode(t, c.Domain.Delete(ctx, d.ID), 404)
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		c := newClient(t)
+
+		_, err := c.Domain.List(canceledContext(), nil)
+		assert.True(t, errors.Is(err, context.Canceled), "expected context canceled, got %v", err)
+	})
+}
+
+// TestRecordService runs the scenarios covering RecordService.
+func TestRecordService(t *testing.T, newClient Factory) {
+	ctx := context.TODO()
+
+	t.Run("create and get", func(t *testing.T) {
+		c := newClient(t)
+		d := mustCreateDomain(t, c, "example.com")
+
+		r, err := c.Record.Create(ctx, globodns.Record{DomainID: d.ID, Name: "www", Type: "A", Content: "169.196.100.100", TTL: globodns.StringPointer("300")})
+		require.NoError(t, err)
+		require.NotNil(t, r)
+		assert.NotZero(t, r.ID)
+		assert.Equal(t, d.ID, r.DomainID)
+		assert.Equal(t, "A", r.Type)
+
+		got, err := c.Record.Get(ctx, r.ID)
+		require.NoError(t, err)
+		assert.Equal(t, r.ID, got.ID)
+		assert.Equal(t, d.ID, got.DomainID)
+		assert.Equal(t, "www", got.Name)
+		assert.Equal(t, "A", got.Type)
+		assert.Equal(t, "169.196.100.100", got.Content)
+		assert.Equal(t, "300", globodns.StringValue(got.TTL))
+	})
+
+	t.Run("create rejects invalid records", func(t *testing.T) {
+		c := newClient(t)
+		d := mustCreateDomain(t, c, "example.com")
+		mustCreateRecord(t, c, globodns.Record{DomainID: d.ID, Name: "www", Type: "A", Content: "169.196.100.100"})
+
+		_, err := c.Record.Create(ctx, globodns.Record{DomainID: d.ID, Name: "www", Type: "A", Content: "169.196.100.100"})
+		assertStatusCode(t, err, 422)
+
+		_, err = c.Record.Create(ctx, globodns.Record{DomainID: d.ID, Name: "www", Type: "CNAME", Content: "example.com."})
+		assertStatusCode(t, err, 422)
+
+		_, err = c.Record.Create(ctx, globodns.Record{DomainID: d.ID, Name: "api", Type: "A"})
+		assertStatusCode(t, err, 422)
+
+		_, err = c.Record.Create(ctx, globodns.Record{DomainID: 666, Name: "www", Type: "A", Content: "169.196.100.100"})
+		assertStatusCode(t, err, 404)
+	})
+
+	t.Run("get unknown record", func(t *testing.T) {
+		c := newClient(t)
+
+		_, err := c.Record.Get(ctx, 666)
+		assertStatusCode(t, err, 404)
+
+		_, err = c.Record.Get(ctx, -1)
+		assert.Error(t, err)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		c := newClient(t)
+		d := mustCreateDomain(t, c, "example.com")
+		other := mustCreateDomain(t, c, "example.org")
+
+		mustCreateRecord(t, c, globodns.Record{DomainID: d.ID, Name: "www", Type: "A", Content: "169.196.100.100"})
+		mustCreateRecord(t, c, globodns.Record{DomainID: d.ID, Name: "www", Type: "AAAA", Content: "2001:db8::1"})
+		mustCreateRecord(t, c, globodns.Record{DomainID: d.ID, Name: "api", Type: "CNAME", Content: "www"})
+		mustCreateRecord(t, c, globodns.Record{DomainID: other.ID, Name: "www", Type: "A", Content: "169.196.100.101"})
+
+		records, err := c.Record.List(ctx, d.ID, nil)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"A www", "AAAA www", "CNAME api"}, recordKeys(records))
+
+		records, err = c.Record.List(ctx, d.ID, &globodns.ListRecordsParameters{Query: "api"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"CNAME api"}, recordKeys(records))
+
+		_, err = c.Record.List(ctx, 666, nil)
+		assertStatusCode(t, err, 404)
+	})
+
+	t.Run("list page", func(t *testing.T) {
+		c := newClient(t)
+		d := mustCreateDomain(t, c, "example.com")
+		for _, name := range []string{"a", "b", "c"} {
+			mustCreateRecord(t, c, globodns.Record{DomainID: d.ID, Name: name, Type: "A", Content: "169.196.100.100"})
+		}
+
+		page, err := c.Record.ListPage(ctx, d.ID, &globodns.ListRecordsParameters{Page: 1, PerPage: 2})
+		require.NoError(t, err)
+		assert.Len(t, page.Records, 2)
+		assert.Equal(t, 3, globodns.IntValue(page.Total))
+		assert.True(t, page.HasNext())
+
+		page, err = c.Record.ListPage(ctx, d.ID, &globodns.ListRecordsParameters{Page: 2, PerPage: 2})
+		require.NoError(t, err)
+		assert.Len(t, page.Records, 1)
+		assert.False(t, page.HasNext())
+	})
+
+	t.Run("update", func(t *testing.T) {
+		c := newClient(t)
+		d := mustCreateDomain(t, c, "example.com")
+		r := mustCreateRecord(t, c, globodns.Record{DomainID: d.ID, Name: "www", Type: "A", Content: "169.196.100.100"})
+
+		require.NoError(t, c.Record.Update(ctx, globodns.Record{ID: r.ID, Name: "www", Type: "A", Content: "169.196.100.200"}))
+
+		got, err := c.Record.Get(ctx, r.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "169.196.100.200", got.Content)
+		assert.Equal(t, d.ID, got.DomainID)
+
+		err = c.Record.Update(ctx, globodns.Record{ID: 666, Name: "www", Type: "A", Content: "169.196.100.200"})
+		assertStatusCode(t, err, 404)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		c := newClient(t)
+		d := mustCreateDomain(t, c, "example.com")
+		r := mustCreateRecord(t, c, globodns.Record{DomainID: d.ID, Name: "www", Type: "A", Content: "169.196.100.100"})
+
+		require.NoError(t, c.Record.Delete(ctx, r.ID))
+
+		_, err := c.Record.Get(ctx, r.ID)
+		assertStatusCode(t, err, 404)
+
+		assertStatusCode(t, c.Record.Delete(ctx, r.ID), 404)
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		c := newClient(t)
+		d := mustCreateDomain(t, c, "example.com")
+
+		_, err := c.Record.Create(canceledContext(), globodns.Record{DomainID: d.ID, Name: "www", Type: "A", Content: "169.196.100.100"})
+		assert.True(t, errors.Is(err, context.Canceled), "expected context canceled, got %v", err)
+
+		records, err := c.Record.List(ctx, d.ID, nil)
+		require.NoError(t, err)
+		assert.Empty(t, records)
+	})
+}
+
+// TestBindService runs the scenarios covering BindService.
+func TestBindService(t *testing.T, newClient Factory) {
+	ctx := context.TODO()
+
+	t.Run("export and wait", func(t *testing.T) {
+		c := newClient(t)
+
+		se, err := c.Bind.Export(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, se)
+		assert.False(t, se.ScheduleDate.IsZero())
+
+		es, err := c.Bind.Wait(ctx, se)
+		require.NoError(t, err)
+		require.NotNil(t, es)
+		assert.True(t, es.Succeeded())
+		require.NotNil(t, es.LastExport)
+		assert.False(t, es.LastExport.Before(se.ScheduleDate))
+	})
+
+	t.Run("wait without schedule date", func(t *testing.T) {
+		c := newClient(t)
+
+		_, err := c.Bind.Wait(ctx, &globodns.ScheduleExport{})
+		assert.Error(t, err)
+	})
+
+	t.Run("export now", func(t *testing.T) {
+		c := newClient(t)
+		mustCreateDomain(t, c, "example.com")
+
+		ie, err := c.Bind.ExportNow(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, ie)
+
+		es, err := c.Bind.LastExport(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, es)
+		assert.True(t, es.Succeeded())
+		assert.NotNil(t, es.LastExport)
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		c := newClient(t)
+
+		_, err := c.Bind.Export(canceledContext())
+		assert.True(t, errors.Is(err, context.Canceled), "expected context canceled, got %v", err)
+	})
+}
+
+func mustCreateDomain(t *testing.T, c *globodns.Client, name string) *globodns.Domain {
+	t.Helper()
+
+	d, err := c.Domain.Create(context.TODO(), globodns.Domain{Name: name})
+	require.NoError(t, err)
+	require.NotNil(t, d)
+	return d
+}
+
+func mustCreateRecord(t *testing.T, c *globodns.Client, r globodns.Record) *globodns.Record {
+	t.Helper()
+
+	created, err := c.Record.Create(context.TODO(), r)
+	require.NoError(t, err)
+	require.NotNil(t, created)
+	return created
+}
+
+func assertStatusCode(t *testing.T, err error, code int) {
+	t.Helper()
+
+	var httpErr *globodns.HTTPError
+	if assert.True(t, errors.As(err, &httpErr), "expected *globodns.HTTPError, got %v", err) {
+		assert.Equal(t, code, httpErr.StatusCode)
+	}
+}
+
+func canceledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	return ctx
+}
+
+func domainNames(ds []globodns.Domain) []string {
+	names := make([]string, 0, len(ds))
+	for _, d := range ds {
+		names = append(names, d.Name)
+	}
+
+	return names
+}
+
+func recordKeys(rs []globodns.Record) []string {
+	keys := make([]string, 0, len(rs))
+	for _, r := range rs {
+		keys = append(keys, r.Type+" "+r.Name)
+	}
+
+	return keys
+}
@@ -0,0 +1,32 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package conformance_test
+
+import (
+	"testing"
+	"time"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/fake"
+	"github.com/tsuru/go-globodnsclient/fake/conformance"
+	"github.com/tsuru/go-globodnsclient/fake/server"
+)
+
+func TestBackend(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) *globodns.Client {
+		return fake.NewBackend().Client()
+	})
+}
+
+func TestServer(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) *globodns.Client {
+		s := server.New(fake.NewBackend())
+		t.Cleanup(s.Close)
+
+		c := s.Client()
+		c.SetExportPollInterval(time.Millisecond)
+		return c
+	})
+}
@@ -0,0 +1,574 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fake
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+)
+
+// Read operations, complementing the mutations defined by globodns.
+const (
+	OperationListDomains globodns.Operation = "domain.list"
+	OperationGetDomain   globodns.Operation = "domain.get"
+	OperationListRecords globodns.Operation = "record.list"
+	OperationGetRecord   globodns.Operation = "record.get"
+	OperationExport      globodns.Operation = "bind.export"
+	OperationExportNow   globodns.Operation = "bind.export_now"
+	OperationLastExport  globodns.Operation = "bind.last_export"
+	OperationWait        globodns.Operation = "bind.wait"
+)
+
+// Target describes a call subject to faults.
+type Target struct {
+	Operation  globodns.Operation
+	DomainID   int
+	RecordID   int
+	RecordName string
+}
+
+// Fault describes a misbehavior injected into calls. The fields above Latency
+// select the calls affected, every call if all of them are empty. Domain IDs
+// and record names can only match calls carrying them, e.g. deleting a record
+// carries only its ID.
+type Fault struct {
+	Operations []globodns.Operation
+	DomainID   int
+	RecordID   int
+
+	// RecordName is a glob, as in path.Match.
+	RecordName string
+
+	// Rate is the probability of the fault happening on a matching call,
+	// always if zero.
+	Rate float64
+
+	// Times limits how many times the fault happens, no limit if zero.
+	Times int
+
+	// Latency delays the call.
+	Latency time.Duration
+
+	// StatusCode makes the call fail with such HTTP status, with Body as the
+	// response body or a JSON error message if empty.
+	StatusCode int
+	Body       string
+
+	// Err makes the call fail with it.
+	Err error
+
+	// TruncateJSON carries out the call but cuts the response body in half,
+	// so the client fails to decode it.
+	TruncateJSON bool
+
+	// CommitThenTimeout carries out the call but times out before the
+	// response arrives, as if the connection was lost after the server
+	// committed the change. Mostly useful with creations. Over the fake
+	// server, the response never arrives, so clients need a deadline.
+	CommitThenTimeout bool
+}
+
+func (f *Fault) match(t Target) bool {
+	if len(f.Operations) > 0 && !containsOperation(f.Operations, t.Operation) {
+		return false
+	}
+
+	if f.DomainID != 0 && f.DomainID != t.DomainID {
+		return false
+	}
+
+	if f.RecordID != 0 && f.RecordID != t.RecordID {
+		return false
+	}
+
+	if f.RecordName == "" {
+		return true
+	}
+
+	ok, _ := path.Match(strings.ToLower(f.RecordName), strings.ToLower(t.RecordName))
+	return ok && t.RecordName != ""
+}
+
+// Wait sleeps for the fault latency, returning early if ctx is done.
+func (f *Fault) Wait(ctx context.Context) error {
+	if f.Latency <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(f.Latency)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Failure returns the error the call fails with before being carried out,
+// nil if the fault does not make it fail.
+func (f *Fault) Failure() error {
+	if f.Err != nil {
+		return f.Err
+	}
+
+	if f.StatusCode == 0 {
+		return nil
+	}
+
+	body := f.Body
+	if body == "" {
+		body = fmt.Sprintf(`{"error":%q}`, strings.ToUpper(http.StatusText(f.StatusCode)))
+	}
+
+	return &globodns.HTTPError{StatusCode: f.StatusCode, Body: []byte(body)}
+}
+
+// Faults holds the faults injected into fake services and the fake server.
+// It is safe for concurrent use.
+type Faults struct {
+	mu     sync.Mutex
+	faults []*Fault
+	counts []int
+	rand   *rand.Rand
+}
+
+func NewFaults() *Faults {
+	return &Faults{rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// Seed makes the rates reproducible.
+func (fs *Faults) Seed(seed int64) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.rand = rand.New(rand.NewSource(seed))
+}
+
+func (fs *Faults) Add(f Fault) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.faults = append(fs.faults, &f)
+	fs.counts = append(fs.counts, 0)
+}
+
+// Reset removes every fault.
+func (fs *Faults) Reset() {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.faults, fs.counts = nil, nil
+}
+
+// Pick returns the first fault happening on a call to t, if any.
+func (fs *Faults) Pick(t Target) (*Fault, bool) {
+	if fs == nil {
+		return nil, false
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for i, f := range fs.faults {
+		if !f.match(t) || (f.Times > 0 && fs.counts[i] >= f.Times) {
+			continue
+		}
+
+		if f.Rate > 0 && fs.random() >= f.Rate {
+			continue
+		}
+
+		fs.counts[i]++
+		fault := *f
+		return &fault, true
+	}
+
+	return nil, false
+}
+
+func (fs *Faults) random() float64 {
+	if fs.rand == nil {
+		fs.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	return fs.rand.Float64()
+}
+
+// before picks the fault for t and applies what happens before the call,
+// i.e. latency and failures.
+func (fs *Faults) before(ctx context.Context, t Target) (*Fault, error) {
+	f, ok := fs.Pick(t)
+	if !ok {
+		return nil, nil
+	}
+
+	if err := f.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	return f, f.Failure()
+}
+
+// after applies what happens once the call was carried out.
+func after(f *Fault, t Target) error {
+	switch {
+	case f == nil:
+		return nil
+
+	case f.CommitThenTimeout:
+		return &url.Error{Op: "Post", URL: string(t.Operation), Err: context.DeadlineExceeded}
+
+	case f.TruncateJSON:
+		return fmt.Errorf("globodns: failed to decode JSON object: unexpected EOF")
+	}
+
+	return nil
+}
+
+func containsOperation(ops []globodns.Operation, op globodns.Operation) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+
+	return false
+}
+
+// InjectFaults replaces the services of c by ones subject to fs.
+func InjectFaults(c *globodns.Client, fs *Faults) {
+	c.Bind = &faultyBindService{next: c.Bind, faults: fs}
+	c.Domain = &faultyDomainService{next: c.Domain, faults: fs}
+	c.Record = &faultyRecordService{next: c.Record, faults: fs}
+}
+
+var _ globodns.BindService = &faultyBindService{}
+
+type faultyBindService struct {
+	next   globodns.BindService
+	faults *Faults
+}
+
+func (s *faultyBindService) Export(ctx context.Context) (*globodns.ScheduleExport, error) {
+	t := Target{Operation: OperationExport}
+
+	f, err := s.faults.before(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	se, err := s.next.Export(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = after(f, t); err != nil {
+		return nil, err
+	}
+
+	return se, nil
+}
+
+func (s *faultyBindService) ExportNow(ctx context.Context) (*globodns.ImmediateExport, error) {
+	t := Target{Operation: OperationExportNow}
+
+	f, err := s.faults.before(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	ie, err := s.next.ExportNow(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = after(f, t); err != nil {
+		return nil, err
+	}
+
+	return ie, nil
+}
+
+func (s *faultyBindService) LastExport(ctx context.Context) (*globodns.ExportStatus, error) {
+	t := Target{Operation: OperationLastExport}
+
+	f, err := s.faults.before(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	es, err := s.next.LastExport(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = after(f, t); err != nil {
+		return nil, err
+	}
+
+	return es, nil
+}
+
+func (s *faultyBindService) Wait(ctx context.Context, se *globodns.ScheduleExport) (*globodns.ExportStatus, error) {
+	t := Target{Operation: OperationWait}
+
+	f, err := s.faults.before(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	es, err := s.next.Wait(ctx, se)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = after(f, t); err != nil {
+		return nil, err
+	}
+
+	return es, nil
+}
+
+var _ globodns.DomainService = &faultyDomainService{}
+
+type faultyDomainService struct {
+	next   globodns.DomainService
+	faults *Faults
+}
+
+func (s *faultyDomainService) Create(ctx context.Context, d globodns.Domain) (*globodns.Domain, error) {
+	t := Target{Operation: globodns.OperationCreateDomain}
+
+	f, err := s.faults.before(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.next.Create(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = after(f, t); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (s *faultyDomainService) Delete(ctx context.Context, domainID int) error {
+	t := Target{Operation: globodns.OperationDeleteDomain, DomainID: domainID}
+
+	f, err := s.faults.before(ctx, t)
+	if err != nil {
+		return err
+	}
+
+	if err = s.next.Delete(ctx, domainID); err != nil {
+		return err
+	}
+
+	return after(f, t)
+}
+
+func (s *faultyDomainService) Get(ctx context.Context, domainID int) (*globodns.Domain, error) {
+	t := Target{Operation: OperationGetDomain, DomainID: domainID}
+
+	f, err := s.faults.before(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := s.next.Get(ctx, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = after(f, t); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (s *faultyDomainService) List(ctx context.Context, p *globodns.ListDomainsParameters) ([]globodns.Domain, error) {
+	t := Target{Operation: OperationListDomains}
+
+	f, err := s.faults.before(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	ds, err := s.next.List(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = after(f, t); err != nil {
+		return nil, err
+	}
+
+	return ds, nil
+}
+
+func (s *faultyDomainService) ListPage(ctx context.Context, p *globodns.ListDomainsParameters) (*globodns.DomainPage, error) {
+	t := Target{Operation: OperationListDomains}
+
+	f, err := s.faults.before(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := s.next.ListPage(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = after(f, t); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func (s *faultyDomainService) Update(ctx context.Context, d globodns.Domain) error {
+	t := Target{Operation: globodns.OperationUpdateDomain, DomainID: d.ID}
+
+	f, err := s.faults.before(ctx, t)
+	if err != nil {
+		return err
+	}
+
+	if err = s.next.Update(ctx, d); err != nil {
+		return err
+	}
+
+	return after(f, t)
+}
+
+var _ globodns.RecordService = &faultyRecordService{}
+
+type faultyRecordService struct {
+	next   globodns.RecordService
+	faults *Faults
+}
+
+func (s *faultyRecordService) Create(ctx context.Context, r globodns.Record) (*globodns.Record, error) {
+	t := Target{Operation: globodns.OperationCreateRecord, DomainID: r.DomainID, RecordName: r.Name}
+
+	f, err := s.faults.before(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.next.Create(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = after(f, t); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (s *faultyRecordService) Delete(ctx context.Context, recordID int) error {
+	t := Target{Operation: globodns.OperationDeleteRecord, RecordID: recordID}
+
+	f, err := s.faults.before(ctx, t)
+	if err != nil {
+		return err
+	}
+
+	if err = s.next.Delete(ctx, recordID); err != nil {
+		return err
+	}
+
+	return after(f, t)
+}
+
+func (s *faultyRecordService) Get(ctx context.Context, recordID int) (*globodns.Record, error) {
+	t := Target{Operation: OperationGetRecord, RecordID: recordID}
+
+	f, err := s.faults.before(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := s.next.Get(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = after(f, t); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (s *faultyRecordService) List(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) ([]globodns.Record, error) {
+	t := Target{Operation: OperationListRecords, DomainID: domainID}
+
+	f, err := s.faults.before(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := s.next.List(ctx, domainID, p)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = after(f, t); err != nil {
+		return nil, err
+	}
+
+	return rs, nil
+}
+
+func (s *faultyRecordService) ListPage(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) (*globodns.RecordPage, error) {
+	t := Target{Operation: OperationListRecords, DomainID: domainID}
+
+	f, err := s.faults.before(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := s.next.ListPage(ctx, domainID, p)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = after(f, t); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func (s *faultyRecordService) Update(ctx context.Context, r globodns.Record) error {
+	t := Target{Operation: globodns.OperationUpdateRecord, DomainID: r.DomainID, RecordID: r.ID, RecordName: r.Name}
+
+	f, err := s.faults.before(ctx, t)
+	if err != nil {
+		return err
+	}
+
+	if err = s.next.Update(ctx, r); err != nil {
+		return err
+	}
+
+	return after(f, t)
+}
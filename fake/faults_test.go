@@ -0,0 +1,148 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fake_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/fake"
+)
+
+func TestInjectFaults(t *testing.T) {
+	tests := map[string]struct {
+		fault         fake.Fault
+		call          func(c *globodns.Client) error
+		expectedError string
+		expectedCount int
+		unavailable   bool
+	}{
+		"status code on a given operation": {
+			fault: fake.Fault{Operations: []globodns.Operation{fake.OperationListRecords}, StatusCode: 503},
+			call: func(c *globodns.Client) error {
+				_, err := c.Record.List(context.TODO(), 10, nil)
+				return err
+			},
+			expectedError: `globodns: unexpected HTTP status code: Code: 503 Body: {"error":"SERVICE UNAVAILABLE"}`,
+			expectedCount: 2,
+			unavailable:   true,
+		},
+
+		"custom error on a given record name": {
+			fault: fake.Fault{RecordName: "api*", Err: fmt.Errorf("boom")},
+			call: func(c *globodns.Client) error {
+				_, err := c.Record.Create(context.TODO(), globodns.Record{DomainID: 10, Name: "api-v2", Type: "A", Content: "169.196.100.101"})
+				return err
+			},
+			expectedError: "boom",
+			expectedCount: 2,
+		},
+
+		"other domains are not affected": {
+			fault: fake.Fault{DomainID: 666, StatusCode: 500},
+			call: func(c *globodns.Client) error {
+				_, err := c.Record.Create(context.TODO(), globodns.Record{DomainID: 10, Name: "api", Type: "A", Content: "169.196.100.101"})
+				return err
+			},
+			expectedCount: 3,
+		},
+
+		"truncated JSON": {
+			fault: fake.Fault{Operations: []globodns.Operation{globodns.OperationCreateRecord}, TruncateJSON: true},
+			call: func(c *globodns.Client) error {
+				_, err := c.Record.Create(context.TODO(), globodns.Record{DomainID: 10, Name: "api", Type: "A", Content: "169.196.100.101"})
+				return err
+			},
+			expectedError: "globodns: failed to decode JSON object: unexpected EOF",
+			expectedCount: 3,
+		},
+
+		"commit then time out": {
+			fault: fake.Fault{Operations: []globodns.Operation{globodns.OperationCreateRecord}, CommitThenTimeout: true},
+			call: func(c *globodns.Client) error {
+				_, err := c.Record.Create(context.TODO(), globodns.Record{DomainID: 10, Name: "api", Type: "A", Content: "169.196.100.101"})
+				return err
+			},
+			expectedError: `Post "record.create": context deadline exceeded`,
+			expectedCount: 3,
+			unavailable:   true,
+		},
+
+		"latency beyond the deadline": {
+			fault: fake.Fault{Latency: time.Second},
+			call: func(c *globodns.Client) error {
+				ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+				defer cancel()
+
+				_, err := c.Domain.Get(ctx, 10)
+				return err
+			},
+			expectedError: "context deadline exceeded",
+			expectedCount: 2,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := newBackend(t)
+			client := b.Client()
+
+			faults := fake.NewFaults()
+			faults.Add(tt.fault)
+			fake.InjectFaults(client, faults)
+
+			err := tt.call(client)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				assert.Equal(t, tt.unavailable, globodns.IsUnavailable(err))
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Len(t, b.Snapshot().Records, tt.expectedCount)
+		})
+	}
+}
+
+func TestFaults_Pick(t *testing.T) {
+	faults := fake.NewFaults()
+	faults.Seed(42)
+	faults.Add(fake.Fault{Operations: []globodns.Operation{globodns.OperationDeleteRecord}, Times: 2, StatusCode: 500})
+	faults.Add(fake.Fault{Operations: []globodns.Operation{fake.OperationGetRecord}, Rate: 0.5, StatusCode: 500})
+
+	target := fake.Target{Operation: globodns.OperationDeleteRecord, RecordID: 1}
+
+	var picked int
+	for i := 0; i < 5; i++ {
+		if _, ok := faults.Pick(target); ok {
+			picked++
+		}
+	}
+
+	assert.Equal(t, 2, picked)
+
+	picked = 0
+	for i := 0; i < 1000; i++ {
+		if _, ok := faults.Pick(fake.Target{Operation: fake.OperationGetRecord}); ok {
+			picked++
+		}
+	}
+
+	assert.InDelta(t, 500, picked, 75)
+
+	faults.Reset()
+	_, ok := faults.Pick(fake.Target{Operation: fake.OperationGetRecord})
+	assert.False(t, ok)
+
+	var nilFaults *fake.Faults
+	_, ok = nilFaults.Pick(target)
+	assert.False(t, ok)
+}
@@ -0,0 +1,314 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/zonefile"
+)
+
+// Fixture is the state of a Backend as written in YAML or JSON files, with
+// records nested in their domains.
+//
+//	domains:
+//	- name: example.com
+//	  ttl: "3600"
+//	  records:
+//	  - {name: www, type: A, content: 169.196.100.100}
+type Fixture struct {
+	Views   map[int]string  `json:"views,omitempty" yaml:"views,omitempty"`
+	Domains []FixtureDomain `json:"domains" yaml:"domains"`
+}
+
+type FixtureDomain struct {
+	ID             int             `json:"id,omitempty" yaml:"id,omitempty"`
+	Name           string          `json:"name" yaml:"name"`
+	AuthorityType  string          `json:"authority_type,omitempty" yaml:"authority_type,omitempty"`
+	AddressingType string          `json:"addressing_type,omitempty" yaml:"addressing_type,omitempty"`
+	ViewID         int             `json:"view_id,omitempty" yaml:"view_id,omitempty"`
+	TTL            string          `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+	Notes          string          `json:"notes,omitempty" yaml:"notes,omitempty"`
+	Records        []FixtureRecord `json:"records,omitempty" yaml:"records,omitempty"`
+}
+
+type FixtureRecord struct {
+	ID      int    `json:"id,omitempty" yaml:"id,omitempty"`
+	Name    string `json:"name" yaml:"name"`
+	Type    string `json:"type" yaml:"type"`
+	Content string `json:"content" yaml:"content"`
+	TTL     string `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+	Prio    *int   `json:"prio,omitempty" yaml:"prio,omitempty"`
+}
+
+// LoadFixture adds the domains and records of f to the backend. IDs left
+// blank are assigned as in Seed.
+func (b *Backend) LoadFixture(f Fixture) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for id, name := range f.Views {
+		b.views[id] = name
+	}
+
+	for _, fd := range f.Domains {
+		d, err := b.seedDomain(globodns.Domain{
+			ID:             fd.ID,
+			Name:           fd.Name,
+			AuthorityType:  fd.AuthorityType,
+			AddressingType: fd.AddressingType,
+			ViewID:         fd.ViewID,
+			TTL:            optionalString(fd.TTL),
+			Notes:          optionalString(fd.Notes),
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, fr := range fd.Records {
+			err = b.seedRecord(globodns.Record{
+				ID:       fr.ID,
+				DomainID: d.ID,
+				Name:     fr.Name,
+				Type:     fr.Type,
+				Content:  fr.Content,
+				TTL:      optionalString(fr.TTL),
+				Prio:     fr.Prio,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// LoadYAML loads a YAML fixture, see Fixture.
+func (b *Backend) LoadYAML(r io.Reader) error {
+	var f Fixture
+
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && err != io.EOF {
+		return fmt.Errorf("fake: could not decode YAML fixture: %w", err)
+	}
+
+	return b.LoadFixture(f)
+}
+
+// LoadJSON loads a JSON fixture, see Fixture.
+func (b *Backend) LoadJSON(r io.Reader) error {
+	var f Fixture
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&f); err != nil {
+		return fmt.Errorf("fake: could not decode JSON fixture: %w", err)
+	}
+
+	return b.LoadFixture(f)
+}
+
+// LoadFile loads the fixture at path, either YAML or JSON according to its
+// extension.
+func (b *Backend) LoadFile(path string) error {
+	load := b.LoadYAML
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+	case ".json":
+		load = b.LoadJSON
+	default:
+		return fmt.Errorf("fake: unknown fixture extension %q", ext)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return load(f)
+}
+
+// LoadZone loads the records of a BIND master file into domain d, which is
+// created unless there's already a domain with its name.
+func (b *Backend) LoadZone(r io.Reader, d globodns.Domain) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	d, err := b.zoneDomain(d)
+	if err != nil {
+		return err
+	}
+
+	records, err := zonefile.Parse(r, d)
+	if err != nil {
+		return err
+	}
+
+	return b.seedRecords(records)
+}
+
+// LoadZoneFile is like LoadZone, reading the master file at path.
+func (b *Backend) LoadZoneFile(path string, d globodns.Domain) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	d, err := b.zoneDomain(d)
+	if err != nil {
+		return err
+	}
+
+	records, err := zonefile.ParseFile(path, d)
+	if err != nil {
+		return err
+	}
+
+	return b.seedRecords(records)
+}
+
+func (b *Backend) zoneDomain(d globodns.Domain) (globodns.Domain, error) {
+	if d.Name == "" {
+		return d, fmt.Errorf("fake: domain name cannot be empty")
+	}
+
+	for _, existing := range b.domains {
+		if normalizeDomainName(existing.Name) == normalizeDomainName(d.Name) {
+			return existing, nil
+		}
+	}
+
+	return b.seedDomain(d)
+}
+
+func (b *Backend) seedRecords(records []globodns.Record) error {
+	for _, r := range records {
+		if err := b.seedRecord(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Fixture returns the stored state as a fixture. IDs and timestamps are left
+// out and everything is sorted by name, so dumps only change along with the
+// DNS data.
+func (b *Backend) Fixture() Fixture {
+	s := b.Snapshot()
+
+	f := Fixture{Domains: []FixtureDomain{}}
+	if len(s.Views) > 0 {
+		f.Views = s.Views
+	}
+
+	sort.SliceStable(s.Domains, func(i, j int) bool { return s.Domains[i].Name < s.Domains[j].Name })
+	sortRecords(s.Records)
+
+	for _, d := range s.Domains {
+		fd := FixtureDomain{
+			Name:           d.Name,
+			AuthorityType:  d.AuthorityType,
+			AddressingType: d.AddressingType,
+			ViewID:         d.ViewID,
+			TTL:            globodns.StringValue(d.TTL),
+			Notes:          globodns.StringValue(d.Notes),
+		}
+
+		for _, r := range s.Records {
+			if r.DomainID != d.ID {
+				continue
+			}
+
+			fd.Records = append(fd.Records, FixtureRecord{
+				Name:    r.Name,
+				Type:    r.Type,
+				Content: r.Content,
+				TTL:     globodns.StringValue(r.TTL),
+				Prio:    r.Prio,
+			})
+		}
+
+		f.Domains = append(f.Domains, fd)
+	}
+
+	return f
+}
+
+// DumpYAML writes the stored state as a YAML fixture, see Fixture.
+func (b *Backend) DumpYAML(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(b.Fixture()); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// DumpJSON writes the stored state as a JSON fixture, see Fixture.
+func (b *Backend) DumpJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(b.Fixture())
+}
+
+// DumpZone writes the BIND master file of the domain with the given name,
+// which must have a SOA record.
+func (b *Backend) DumpZone(w io.Writer, name string) error {
+	s := b.Snapshot()
+
+	for _, d := range s.Domains {
+		if normalizeDomainName(d.Name) != normalizeDomainName(name) {
+			continue
+		}
+
+		var records []globodns.Record
+		for _, r := range s.Records {
+			if r.DomainID == d.ID {
+				records = append(records, r)
+			}
+		}
+
+		sortRecords(records)
+		return zonefile.Write(w, d, records)
+	}
+
+	return fmt.Errorf("fake: domain %s not found", name)
+}
+
+func sortRecords(records []globodns.Record) {
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Name != records[j].Name {
+			return records[i].Name < records[j].Name
+		}
+
+		if records[i].Type != records[j].Type {
+			return records[i].Type < records[j].Type
+		}
+
+		return records[i].Content < records[j].Content
+	})
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
@@ -0,0 +1,161 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fake_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/fake"
+)
+
+const yamlFixture = `views:
+  1: internal
+domains:
+  - name: example.com
+    addressing_type: "N"
+    view_id: 1
+    ttl: "3600"
+    records:
+      - name: www
+        type: A
+        content: 169.196.100.100
+      - name: '@'
+        type: MX
+        content: mail.example.com.
+        prio: 10
+  - name: 100.196.169.in-addr.arpa
+    addressing_type: R
+    records:
+      - name: "100"
+        type: PTR
+        content: www.example.com.
+`
+
+func TestBackend_LoadYAML(t *testing.T) {
+	b := fake.NewBackend()
+	require.NoError(t, b.LoadYAML(strings.NewReader(yamlFixture)))
+
+	s := b.Snapshot()
+	assert.Equal(t, map[int]string{1: "internal"}, s.Views)
+	assert.Equal(t, []globodns.Domain{
+		{ID: 1, Name: "example.com", AddressingType: "N", ViewID: 1, TTL: globodns.StringPointer("3600")},
+		{ID: 2, Name: "100.196.169.in-addr.arpa", AddressingType: "R"},
+	}, s.Domains)
+
+	prio := 10
+	assert.Equal(t, []globodns.Record{
+		{ID: 1, DomainID: 1, Name: "www", Type: "A", Content: "169.196.100.100"},
+		{ID: 2, DomainID: 1, Name: "@", Type: "MX", Content: "mail.example.com.", Prio: &prio},
+		{ID: 3, DomainID: 2, Name: "100", Type: "PTR", Content: "www.example.com."},
+	}, s.Records)
+
+	var out bytes.Buffer
+	require.NoError(t, b.DumpYAML(&out))
+	assert.Equal(t, `views:
+  1: internal
+domains:
+  - name: 100.196.169.in-addr.arpa
+    addressing_type: R
+    records:
+      - name: "100"
+        type: PTR
+        content: www.example.com.
+  - name: example.com
+    addressing_type: "N"
+    view_id: 1
+    ttl: "3600"
+    records:
+      - name: '@'
+        type: MX
+        content: mail.example.com.
+        prio: 10
+      - name: www
+        type: A
+        content: 169.196.100.100
+`, out.String())
+
+	reloaded := fake.NewBackend()
+	require.NoError(t, reloaded.LoadYAML(&out))
+	assert.Equal(t, b.Fixture(), reloaded.Fixture())
+}
+
+func TestBackend_LoadJSON(t *testing.T) {
+	b := fake.NewBackend()
+	require.NoError(t, b.LoadJSON(strings.NewReader(`{"domains": [{"name": "example.com", "records": [{"name": "www", "type": "a", "content": "169.196.100.100", "ttl": "300"}]}]}`)))
+
+	_, err := b.Client().Record.Create(context.TODO(), globodns.Record{DomainID: 1, Name: "api", Type: "CNAME", Content: "www"})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, b.DumpJSON(&out))
+	assert.JSONEq(t, `{
+		"domains": [{
+			"name": "example.com",
+			"records": [
+				{"name": "api", "type": "CNAME", "content": "www"},
+				{"name": "www", "type": "A", "content": "169.196.100.100", "ttl": "300"}
+			]
+		}]
+	}`, out.String())
+
+	err = b.LoadJSON(strings.NewReader(`{"domains": [{"name": "example.org", "unknown": true}]}`))
+	assert.EqualError(t, err, `fake: could not decode JSON fixture: json: unknown field "unknown"`)
+}
+
+func TestBackend_LoadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "globodns-fixtures")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "fixture.yml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(yamlFixture), 0644))
+
+	b := fake.NewBackend()
+	require.NoError(t, b.LoadFile(path))
+	assert.Len(t, b.Snapshot().Records, 3)
+
+	assert.EqualError(t, b.LoadFile(filepath.Join(dir, "fixture.txt")), `fake: unknown fixture extension ".txt"`)
+}
+
+func TestBackend_LoadZone(t *testing.T) {
+	zone := `$ORIGIN example.org.
+$TTL 3600
+@	IN	SOA	ns1.example.com. hostmaster.example.com. 2021102901 10800 3600 604800 3600
+@	IN	NS	ns1.example.com.
+www	300	IN	A	169.196.100.100
+api	IN	CNAME	www
+`
+
+	b := fake.NewBackend()
+	require.NoError(t, b.LoadYAML(strings.NewReader(yamlFixture)))
+	require.NoError(t, b.LoadZone(strings.NewReader(zone), globodns.Domain{Name: "example.org", TTL: globodns.StringPointer("3600")}))
+
+	records, err := b.Client().Record.List(context.TODO(), 3, nil)
+	require.NoError(t, err)
+	assert.Len(t, records, 4)
+
+	var out bytes.Buffer
+	require.NoError(t, b.DumpZone(&out, "example.org."))
+	assert.Equal(t, `$ORIGIN example.org.
+$TTL 3600
+
+@		IN	SOA	ns1.example.com. hostmaster.example.com. 2021102901 10800 3600 604800 3600
+@		IN	NS	ns1.example.com.
+api		IN	CNAME	www
+www	300	IN	A	169.196.100.100
+`, out.String())
+
+	assert.EqualError(t, b.DumpZone(&out, "example.net"), "fake: domain example.net not found")
+}
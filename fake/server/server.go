@@ -0,0 +1,557 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package server provides a local HTTP stand-in for GloboDNS, serving the
+// REST endpoints used by the client from a fake.Backend.
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/fake"
+)
+
+const DefaultToken = "fake-globodns-token"
+
+// dateLayout is the layout GloboDNS uses to format dates.
+const dateLayout = "2006-01-02 15:04:05 -0700"
+
+var (
+	domainPath        = regexp.MustCompile(`^/domains/(\d+)\.json$`)
+	domainRecordsPath = regexp.MustCompile(`^/domains/(\d+)/records\.json$`)
+	recordPath        = regexp.MustCompile(`^/records/(\d+)\.json$`)
+)
+
+// Server serves the GloboDNS API over a httptest.Server. Every request but
+// the sign-in must carry the token in the X-Auth-Token header.
+type Server struct {
+	*httptest.Server
+
+	Backend *fake.Backend
+
+	// Faults are injected into the requests, none if nil.
+	Faults *fake.Faults
+
+	mu    sync.Mutex
+	token string
+	users map[string]string
+
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+// New starts a server backed by b, or by an empty backend if nil. Callers
+// must Close it when done.
+func New(b *fake.Backend) *Server {
+	if b == nil {
+		b = fake.NewBackend()
+	}
+
+	s := &Server{
+		Backend: b,
+		token:   DefaultToken,
+		users:   make(map[string]string),
+		closing: make(chan struct{}),
+	}
+
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Close releases the requests left hanging by faults and shuts the server
+// down.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() { close(s.closing) })
+	s.Server.Close()
+}
+
+// SetToken changes the token accepted by the server, an empty one disables
+// the authentication.
+func (s *Server) SetToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+}
+
+func (s *Server) Token() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token
+}
+
+// AddUser registers the credentials accepted by the sign-in endpoint.
+func (s *Server) AddUser(email, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[email] = password
+}
+
+// Client returns a client pointed to the server, already authenticated.
+func (s *Server) Client() *globodns.Client {
+	c, err := globodns.New(s.Server.Client(), s.URL)
+	if err != nil {
+		panic(err)
+	}
+
+	c.SetToken(s.Token())
+	return c
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if r.Method == "POST" && r.URL.Path == "/users/sign_in.json" {
+		s.signIn(w, r)
+		return
+	}
+
+	if token := s.Token(); token != "" && r.Header.Get("X-Auth-Token") != token {
+		writeError(w, http.StatusUnauthorized, "You need to sign in or sign up before continuing.")
+		return
+	}
+
+	t := target(r)
+
+	fault, ok := s.Faults.Pick(t)
+	if !ok {
+		s.route(w, r)
+		return
+	}
+
+	if err := fault.Wait(r.Context()); err != nil {
+		writeError(w, http.StatusGatewayTimeout, err.Error())
+		return
+	}
+
+	if err := fault.Failure(); err != nil {
+		writeResult(w, 0, nil, err)
+		return
+	}
+
+	if !fault.TruncateJSON && !fault.CommitThenTimeout {
+		s.route(w, r)
+		return
+	}
+
+	rec := httptest.NewRecorder()
+	s.route(rec, r)
+
+	if fault.CommitThenTimeout {
+		s.hangUp(w, r)
+		return
+	}
+
+	for k, v := range rec.Header() {
+		w.Header()[k] = v
+	}
+
+	body := rec.Body.Bytes()
+	w.WriteHeader(rec.Code)
+	w.Write(body[:len(body)/2])
+}
+
+// hangUp never answers r: it waits for the client to give up, so it fails
+// with its own deadline as it would against the fake services, and then drops
+// the connection. Clients without a deadline only fail once the server is
+// closed.
+func (s *Server) hangUp(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-r.Context().Done():
+	case <-s.closing:
+	}
+
+	if hj, ok := w.(http.Hijacker); ok {
+		if conn, _, err := hj.Hijack(); err == nil {
+			conn.Close()
+		}
+	}
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	path := r.URL.Path
+
+	switch {
+	case path == "/domains" || path == "/domains.json":
+		switch r.Method {
+		case "GET":
+			s.listDomains(ctx, w, r)
+		case "POST":
+			s.createDomain(ctx, w, r)
+		default:
+			methodNotAllowed(w)
+		}
+
+	case domainPath.MatchString(path):
+		id := pathID(domainPath, path)
+
+		switch r.Method {
+		case "GET":
+			d, err := s.Backend.Domain.Get(ctx, id)
+			writeResult(w, http.StatusOK, map[string]interface{}{"domain": d}, err)
+		case "PUT", "PATCH":
+			var body struct {
+				Domain globodns.Domain `json:"domain"`
+			}
+
+			if !decode(w, r, &body) {
+				return
+			}
+
+			body.Domain.ID = id
+			writeResult(w, http.StatusNoContent, nil, s.Backend.Domain.Update(ctx, body.Domain))
+		case "DELETE":
+			writeResult(w, http.StatusNoContent, nil, s.Backend.Domain.Delete(ctx, id))
+		default:
+			methodNotAllowed(w)
+		}
+
+	case domainRecordsPath.MatchString(path):
+		id := pathID(domainRecordsPath, path)
+
+		switch r.Method {
+		case "GET":
+			s.listRecords(ctx, w, r, id)
+		case "POST":
+			var body struct {
+				Record globodns.Record `json:"record"`
+			}
+
+			if !decode(w, r, &body) {
+				return
+			}
+
+			body.Record.DomainID = id
+			created, err := s.Backend.Record.Create(ctx, body.Record)
+			writeResult(w, http.StatusCreated, map[string]interface{}{"record": created}, err)
+		default:
+			methodNotAllowed(w)
+		}
+
+	case recordPath.MatchString(path):
+		id := pathID(recordPath, path)
+
+		switch r.Method {
+		case "GET":
+			record, err := s.Backend.Record.Get(ctx, id)
+			if err != nil {
+				writeResult(w, 0, nil, err)
+				return
+			}
+
+			writeResult(w, http.StatusOK, map[string]interface{}{strings.ToLower(record.Type): record}, nil)
+		case "PUT", "PATCH":
+			var body struct {
+				Record globodns.Record `json:"record"`
+			}
+
+			if !decode(w, r, &body) {
+				return
+			}
+
+			body.Record.ID = id
+			writeResult(w, http.StatusNoContent, nil, s.Backend.Record.Update(ctx, body.Record))
+		case "DELETE":
+			writeResult(w, http.StatusNoContent, nil, s.Backend.Record.Delete(ctx, id))
+		default:
+			methodNotAllowed(w)
+		}
+
+	case path == "/bind9/schedule_export.json" && r.Method == "POST":
+		se, err := s.Backend.Bind.Export(ctx)
+		writeResult(w, http.StatusOK, se, err)
+
+	case path == "/bind9/export.json" && r.Method == "POST":
+		ie, err := s.Backend.Bind.ExportNow(ctx)
+		if err != nil {
+			writeResult(w, 0, nil, err)
+			return
+		}
+
+		writeResult(w, http.StatusOK, map[string]string{"output": ie.Output}, nil)
+
+	case path == "/bind9/export_status.json" && r.Method == "GET":
+		es, err := s.Backend.Bind.LastExport(ctx)
+		if err != nil {
+			writeResult(w, 0, nil, err)
+			return
+		}
+
+		writeResult(w, http.StatusOK, map[string]interface{}{
+			"status":        es.Status,
+			"output":        es.Output,
+			"last_export":   formatDate(es.LastExport),
+			"schedule_date": formatDate(es.ScheduleDate),
+		}, nil)
+
+	default:
+		writeError(w, http.StatusNotFound, "NOT FOUND")
+	}
+}
+
+// target describes the request in terms of faults, peeking at the body for
+// the record name.
+func target(r *http.Request) fake.Target {
+	path := r.URL.Path
+
+	switch {
+	case path == "/domains" || path == "/domains.json":
+		if r.Method == "POST" {
+			return fake.Target{Operation: globodns.OperationCreateDomain}
+		}
+
+		return fake.Target{Operation: fake.OperationListDomains}
+
+	case domainPath.MatchString(path):
+		t := fake.Target{DomainID: pathID(domainPath, path)}
+		switch r.Method {
+		case "PUT", "PATCH":
+			t.Operation = globodns.OperationUpdateDomain
+		case "DELETE":
+			t.Operation = globodns.OperationDeleteDomain
+		default:
+			t.Operation = fake.OperationGetDomain
+		}
+
+		return t
+
+	case domainRecordsPath.MatchString(path):
+		t := fake.Target{DomainID: pathID(domainRecordsPath, path), Operation: fake.OperationListRecords}
+		if r.Method == "POST" {
+			t.Operation = globodns.OperationCreateRecord
+			t.RecordName = peekRecordName(r)
+		}
+
+		return t
+
+	case recordPath.MatchString(path):
+		t := fake.Target{RecordID: pathID(recordPath, path)}
+		switch r.Method {
+		case "PUT", "PATCH":
+			t.Operation = globodns.OperationUpdateRecord
+			t.RecordName = peekRecordName(r)
+		case "DELETE":
+			t.Operation = globodns.OperationDeleteRecord
+		default:
+			t.Operation = fake.OperationGetRecord
+		}
+
+		return t
+
+	case path == "/bind9/schedule_export.json":
+		return fake.Target{Operation: fake.OperationExport}
+
+	case path == "/bind9/export.json":
+		return fake.Target{Operation: fake.OperationExportNow}
+
+	case path == "/bind9/export_status.json":
+		return fake.Target{Operation: fake.OperationLastExport}
+	}
+
+	return fake.Target{}
+}
+
+func peekRecordName(r *http.Request) string {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return ""
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	var body struct {
+		Record struct {
+			Name string `json:"name"`
+		} `json:"record"`
+	}
+
+	json.Unmarshal(data, &body)
+	return body.Record.Name
+}
+
+func (s *Server) signIn(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		User struct {
+			Email    string `json:"email"`
+			Password string `json:"password"`
+		} `json:"user"`
+	}
+
+	if !decode(w, r, &body) {
+		return
+	}
+
+	s.mu.Lock()
+	password, found := s.users[body.User.Email]
+	token := s.token
+	s.mu.Unlock()
+
+	if !found || password != body.User.Password {
+		writeError(w, http.StatusUnauthorized, "Invalid email or password.")
+		return
+	}
+
+	writeResult(w, http.StatusCreated, map[string]string{
+		"email":                body.User.Email,
+		"authentication_token": token,
+	}, nil)
+}
+
+func (s *Server) listDomains(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	p := &globodns.ListDomainsParameters{
+		Query:   q.Get("query"),
+		View:    q.Get("view"),
+		Reverse: queryBool(q, "reverse"),
+		Page:    queryInt(q, "page"),
+		PerPage: queryInt(q, "per_page"),
+	}
+
+	page, err := s.Backend.Domain.ListPage(ctx, p)
+	if err != nil {
+		writeResult(w, 0, nil, err)
+		return
+	}
+
+	got := make([]map[string]globodns.Domain, 0, len(page.Domains))
+	for _, d := range page.Domains {
+		got = append(got, map[string]globodns.Domain{"domain": d})
+	}
+
+	setPaginationHeaders(w, page.Pagination)
+	writeResult(w, http.StatusOK, got, nil)
+}
+
+func (s *Server) createDomain(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Domain globodns.Domain `json:"domain"`
+	}
+
+	if !decode(w, r, &body) {
+		return
+	}
+
+	created, err := s.Backend.Domain.Create(ctx, body.Domain)
+	writeResult(w, http.StatusCreated, map[string]interface{}{"domain": created}, err)
+}
+
+func (s *Server) listRecords(ctx context.Context, w http.ResponseWriter, r *http.Request, domainID int) {
+	q := r.URL.Query()
+	p := &globodns.ListRecordsParameters{
+		Query:   q.Get("query"),
+		Reverse: queryBool(q, "reverse"),
+		Page:    queryInt(q, "page"),
+		PerPage: queryInt(q, "per_page"),
+	}
+
+	page, err := s.Backend.Record.ListPage(ctx, domainID, p)
+	if err != nil {
+		writeResult(w, 0, nil, err)
+		return
+	}
+
+	got := make([]map[string]globodns.Record, 0, len(page.Records))
+	for _, record := range page.Records {
+		got = append(got, map[string]globodns.Record{strings.ToLower(record.Type): record})
+	}
+
+	setPaginationHeaders(w, page.Pagination)
+	writeResult(w, http.StatusOK, got, nil)
+}
+
+func setPaginationHeaders(w http.ResponseWriter, p globodns.Pagination) {
+	h := w.Header()
+	h.Set("X-Page", strconv.Itoa(p.Page))
+	h.Set("X-Per-Page", strconv.Itoa(p.PerPage))
+
+	if p.Total != nil {
+		h.Set("X-Total-Count", strconv.Itoa(*p.Total))
+	}
+
+	if p.TotalPages != nil {
+		h.Set("X-Total-Pages", strconv.Itoa(*p.TotalPages))
+	}
+
+	if p.NextPage != nil {
+		h.Set("X-Next-Page", strconv.Itoa(*p.NextPage))
+	}
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON body: %s", err))
+		return false
+	}
+
+	return true
+}
+
+func writeResult(w http.ResponseWriter, code int, v interface{}, err error) {
+	if err != nil {
+		var httpErr *globodns.HTTPError
+		if errors.As(err, &httpErr) {
+			w.WriteHeader(httpErr.StatusCode)
+			w.Write(httpErr.Body)
+			return
+		}
+
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(code)
+	if v != nil && code != http.StatusNoContent {
+		json.NewEncoder(w).Encode(v)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+func methodNotAllowed(w http.ResponseWriter) {
+	writeError(w, http.StatusMethodNotAllowed, "METHOD NOT ALLOWED")
+}
+
+func pathID(re *regexp.Regexp, path string) int {
+	id, _ := strconv.Atoi(re.FindStringSubmatch(path)[1])
+	return id
+}
+
+func queryInt(q url.Values, key string) int {
+	n, _ := strconv.Atoi(q.Get(key))
+	return n
+}
+
+func queryBool(q url.Values, key string) *bool {
+	b, err := strconv.ParseBool(q.Get(key))
+	if err != nil {
+		return nil
+	}
+
+	return &b
+}
+
+func formatDate(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+
+	return t.Format(dateLayout)
+}
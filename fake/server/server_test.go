@@ -0,0 +1,235 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/fake"
+	"github.com/tsuru/go-globodnsclient/fake/server"
+)
+
+func newServer(t *testing.T) *server.Server {
+	b := fake.NewBackend()
+	require.NoError(t, b.Seed(fake.State{
+		Domains: []globodns.Domain{{ID: 10, Name: "example.com", AddressingType: "N"}},
+		Records: []globodns.Record{{ID: 1, DomainID: 10, Name: "www", Type: "A", Content: "169.196.100.100"}},
+	}))
+
+	s := server.New(b)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestServer_Client(t *testing.T) {
+	s := newServer(t)
+	client := s.Client()
+	ctx := context.TODO()
+
+	domains, err := client.Domain.List(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []globodns.Domain{{ID: 10, Name: "example.com", AddressingType: "N"}}, domains)
+
+	d, err := client.Domain.Create(ctx, globodns.Domain{Name: "example.org", TTL: globodns.StringPointer("3600")})
+	require.NoError(t, err)
+	assert.Equal(t, 11, d.ID)
+
+	require.NoError(t, client.Domain.Update(ctx, globodns.Domain{ID: 11, Name: "example.net"}))
+
+	d, err = client.Domain.Get(ctx, 11)
+	require.NoError(t, err)
+	assert.Equal(t, "example.net", d.Name)
+	assert.Equal(t, "3600", globodns.StringValue(d.TTL))
+
+	r, err := client.Record.Create(ctx, globodns.Record{DomainID: 10, Name: "api", Type: "CNAME", Content: "www"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, r.ID)
+	assert.Equal(t, "CNAME", r.Type)
+
+	require.NoError(t, client.Record.Update(ctx, globodns.Record{ID: 2, Name: "api", Type: "CNAME", Content: "www.example.com."}))
+
+	r, err = client.Record.Get(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "www.example.com.", r.Content)
+	assert.Equal(t, "CNAME", r.Type)
+
+	page, err := client.Record.ListPage(ctx, 10, &globodns.ListRecordsParameters{PerPage: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 2, globodns.IntValue(page.Total))
+	assert.True(t, page.HasNext())
+	assert.Equal(t, "api", page.Records[0].Name)
+
+	records, err := client.Record.List(ctx, 10, nil)
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	require.NoError(t, client.Record.Delete(ctx, 2))
+	require.NoError(t, client.Domain.Delete(ctx, 11))
+
+	_, err = client.Record.Get(ctx, 2)
+	assert.EqualError(t, err, `globodns: unexpected HTTP status code: Code: 404 Body: {"error":"NOT FOUND"}`)
+
+	_, err = client.Record.Create(ctx, globodns.Record{DomainID: 10, Name: "www", Type: "A", Content: "169.196.100.100"})
+	assert.EqualError(t, err, `globodns: unexpected HTTP status code: Code: 422 Body: {"errors":{"name":["has already been taken"]}}`)
+
+	client.SetExportPollInterval(time.Millisecond)
+
+	se, err := client.Bind.Export(ctx)
+	require.NoError(t, err)
+
+	s.Backend.Recorder.Reset()
+
+	es, err := client.Bind.Wait(ctx, se)
+	require.NoError(t, err)
+	assert.True(t, es.Succeeded())
+	assert.False(t, es.LastExport.Before(se.ScheduleDate))
+	s.Backend.Recorder.AssertCount(t, "Bind.LastExport", 1)
+	s.Backend.Recorder.AssertCount(t, "Bind.ExportNow", 0)
+	s.Backend.Recorder.AssertCount(t, "Bind.Export", 0)
+}
+
+func TestServer_Authentication(t *testing.T) {
+	s := newServer(t)
+	s.AddUser("admin@example.com", "secret")
+
+	client, err := globodns.New(nil, s.URL)
+	require.NoError(t, err)
+
+	_, err = client.Domain.List(context.TODO(), nil)
+	assert.EqualError(t, err, `globodns: unexpected HTTP status code: Code: 401 Body: {"error":"You need to sign in or sign up before continuing."}`+"\n")
+
+	_, err = client.SignIn(context.TODO(), "admin@example.com", "wrong")
+	assert.EqualError(t, err, `globodns: unexpected HTTP status code: Code: 401 Body: {"error":"Invalid email or password."}`+"\n")
+
+	token, err := client.SignIn(context.TODO(), "admin@example.com", "secret")
+	require.NoError(t, err)
+	assert.Equal(t, server.DefaultToken, token)
+
+	_, err = client.Domain.List(context.TODO(), nil)
+	require.NoError(t, err)
+}
+
+func TestServer_Errors(t *testing.T) {
+	s := newServer(t)
+
+	tests := map[string]struct {
+		method       string
+		path         string
+		body         string
+		expectedCode int
+		expectedBody string
+	}{
+		"unknown path": {
+			method:       "GET",
+			path:         "/unknown",
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"error":"NOT FOUND"}` + "\n",
+		},
+
+		"unknown domain": {
+			method:       "GET",
+			path:         "/domains/666.json",
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"error":"NOT FOUND"}`,
+		},
+
+		"invalid JSON": {
+			method:       "POST",
+			path:         "/domains.json",
+			body:         `{"domain":`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"error":"invalid JSON body: unexpected EOF"}` + "\n",
+		},
+
+		"blank domain name": {
+			method:       "POST",
+			path:         "/domains.json",
+			body:         `{"domain": {"name": ""}}`,
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedBody: `{"errors":{"name":["can't be blank"]}}`,
+		},
+
+		"method not allowed": {
+			method:       "DELETE",
+			path:         "/domains.json",
+			expectedCode: http.StatusMethodNotAllowed,
+			expectedBody: `{"error":"METHOD NOT ALLOWED"}` + "\n",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, s.URL+tt.path, strings.NewReader(tt.body))
+			require.NoError(t, err)
+			req.Header.Set("X-Auth-Token", s.Token())
+
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			body, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+			assert.Equal(t, tt.expectedBody, string(body))
+		})
+	}
+}
+
+func TestServer_Faults(t *testing.T) {
+	s := newServer(t)
+	s.Faults = fake.NewFaults()
+
+	client := s.Client()
+	ctx := context.TODO()
+
+	s.Faults.Add(fake.Fault{Operations: []globodns.Operation{globodns.OperationCreateRecord}, RecordName: "api", CommitThenTimeout: true, Times: 1})
+	s.Faults.Add(fake.Fault{Operations: []globodns.Operation{fake.OperationListRecords}, TruncateJSON: true, Times: 1})
+	s.Faults.Add(fake.Fault{DomainID: 666, StatusCode: http.StatusBadGateway})
+
+	createCtx, cancelCreate := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancelCreate()
+
+	_, err := client.Record.Create(createCtx, globodns.Record{DomainID: 10, Name: "api", Type: "A", Content: "169.196.100.101"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	var urlErr *url.Error
+	assert.True(t, errors.As(err, &urlErr), "expected the same error as the fake services, got %#v", err)
+	assert.True(t, globodns.IsUnavailable(err))
+
+	created, err := s.Backend.Record.List(ctx, 10, &globodns.ListRecordsParameters{Query: "api"})
+	require.NoError(t, err)
+	assert.Len(t, created, 1, "the change should be committed anyway")
+
+	_, err = client.Record.List(ctx, 10, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "globodns: failed to decode JSON object")
+
+	records, err := client.Record.List(ctx, 10, nil)
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	_, err = client.Domain.Get(ctx, 666)
+	assert.EqualError(t, err, `globodns: unexpected HTTP status code: Code: 502 Body: {"error":"BAD GATEWAY"}`)
+
+	s.Faults.Reset()
+	s.Faults.Add(fake.Fault{Latency: 50 * time.Millisecond})
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+
+	_, err = client.Domain.Get(timeoutCtx, 10)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
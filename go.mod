@@ -2,4 +2,8 @@ module github.com/tsuru/go-globodnsclient
 
 go 1.16
 
-require github.com/stretchr/testify v1.7.0
+require (
+	github.com/miekg/dns v1.1.43
+	github.com/stretchr/testify v1.7.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,122 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offline
+
+import (
+	"context"
+	"time"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+)
+
+type DomainsResult struct {
+	Domains []globodns.Domain
+
+	// Stale is set when the domains come from a local snapshot as GloboDNS
+	// could not be reached, Err holds the error returned by the API then.
+	Stale      bool
+	Err        error
+	SnapshotAt time.Time
+	Age        time.Duration
+}
+
+type RecordsResult struct {
+	Records []globodns.Record
+
+	// Stale is set when the records come from a local snapshot as GloboDNS
+	// could not be reached, Err holds the error returned by the API then.
+	Stale      bool
+	Err        error
+	SnapshotAt time.Time
+	Age        time.Duration
+}
+
+// Reader lists domains and records from GloboDNS, saving every successful
+// listing in Store and falling back to it whenever the API is unavailable.
+type Reader struct {
+	Domain globodns.DomainService
+	Record globodns.RecordService
+	Store  *Store
+}
+
+func NewReader(c *globodns.Client, s *Store) *Reader {
+	return &Reader{Domain: c.Domain, Record: c.Record, Store: s}
+}
+
+func (r *Reader) ListDomains(ctx context.Context, p *globodns.ListDomainsParameters) (*DomainsResult, error) {
+	params := copyDomainsParameters(p)
+
+	domains, err := r.Domain.List(ctx, p)
+	if err == nil {
+		// NOTE: failing to save a snapshot must not break online reads.
+		r.Store.SaveDomains(params, domains)
+		return &DomainsResult{Domains: domains}, nil
+	}
+
+	if !globodns.IsUnavailable(err) {
+		return nil, err
+	}
+
+	domains, savedAt, lerr := r.Store.LoadDomains(params)
+	if lerr != nil {
+		return nil, err
+	}
+
+	return &DomainsResult{
+		Domains:    domains,
+		Stale:      true,
+		Err:        err,
+		SnapshotAt: savedAt,
+		Age:        r.Store.now().Sub(savedAt),
+	}, nil
+}
+
+func (r *Reader) ListRecords(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) (*RecordsResult, error) {
+	params := copyRecordsParameters(p)
+
+	records, err := r.Record.List(ctx, domainID, p)
+	if err == nil {
+		// NOTE: failing to save a snapshot must not break online reads.
+		r.Store.SaveRecords(domainID, params, records)
+		return &RecordsResult{Records: records}, nil
+	}
+
+	if !globodns.IsUnavailable(err) {
+		return nil, err
+	}
+
+	records, savedAt, lerr := r.Store.LoadRecords(domainID, params)
+	if lerr != nil {
+		return nil, err
+	}
+
+	return &RecordsResult{
+		Records:    records,
+		Stale:      true,
+		Err:        err,
+		SnapshotAt: savedAt,
+		Age:        r.Store.now().Sub(savedAt),
+	}, nil
+}
+
+// NOTE: the services may change the parameters while fetching every page, so
+// snapshots are keyed by a copy taken before the call.
+func copyDomainsParameters(p *globodns.ListDomainsParameters) *globodns.ListDomainsParameters {
+	if p == nil {
+		return nil
+	}
+
+	params := *p
+	return &params
+}
+
+func copyRecordsParameters(p *globodns.ListRecordsParameters) *globodns.ListRecordsParameters {
+	if p == nil {
+		return nil
+	}
+
+	params := *p
+	return &params
+}
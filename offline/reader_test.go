@@ -0,0 +1,122 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offline_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/offline"
+)
+
+func TestReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "globodns-offline")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	status := http.StatusOK
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			fmt.Fprintf(w, "some error")
+			return
+		}
+
+		switch r.URL.Path {
+		case "/domains":
+			if r.URL.Query().Get("page") != "1" {
+				fmt.Fprintf(w, `[]`)
+				return
+			}
+
+			fmt.Fprintf(w, `[{"domain": {"id": 1, "name": "example.com"}}]`)
+
+		case "/domains/1/records.json":
+			if r.URL.Query().Get("page") != "1" {
+				fmt.Fprintf(w, `[]`)
+				return
+			}
+
+			fmt.Fprintf(w, `[{"a": {"id": 10, "name": "www", "content": "169.196.100.100"}}]`)
+		}
+	}))
+	defer server.Close()
+
+	client, err := globodns.New(nil, server.URL)
+	require.NoError(t, err)
+
+	store, err := offline.Open(dir)
+	require.NoError(t, err)
+
+	now := time.Date(2021, 10, 29, 17, 43, 0, 0, time.UTC)
+	store.Now = func() time.Time { return now }
+
+	reader := offline.NewReader(client, store)
+
+	domains, err := reader.ListDomains(context.TODO(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, &offline.DomainsResult{Domains: []globodns.Domain{{ID: 1, Name: "example.com"}}}, domains)
+
+	records, err := reader.ListRecords(context.TODO(), 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &offline.RecordsResult{Records: []globodns.Record{{ID: 10, Name: "www", Content: "169.196.100.100", Type: "A"}}}, records)
+
+	now = now.Add(time.Hour)
+	status = http.StatusServiceUnavailable
+
+	domains, err = reader.ListDomains(context.TODO(), nil)
+	require.NoError(t, err)
+	assert.True(t, domains.Stale)
+	assert.EqualError(t, domains.Err, "globodns: unexpected HTTP status code: Code: 503 Body: some error")
+	assert.Equal(t, time.Hour, domains.Age)
+	assert.Equal(t, []globodns.Domain{{ID: 1, Name: "example.com"}}, domains.Domains)
+
+	server.Close()
+
+	records, err = reader.ListRecords(context.TODO(), 1, nil)
+	require.NoError(t, err)
+	assert.True(t, records.Stale)
+	assert.Error(t, records.Err)
+	assert.Equal(t, time.Hour, records.Age)
+	assert.Equal(t, []globodns.Record{{ID: 10, Name: "www", Content: "169.196.100.100", Type: "A"}}, records.Records)
+
+	_, err = reader.ListRecords(context.TODO(), 2, nil)
+	assert.Error(t, err, "there is no snapshot for this domain")
+}
+
+func TestReader_DoesNotFallBackOnClientErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "globodns-offline")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := offline.Open(dir)
+	require.NoError(t, err)
+
+	err = store.SaveDomains(nil, []globodns.Domain{{ID: 1}})
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(w, `{"error":"NOT AUTHORIZED"}`)
+	}))
+	defer server.Close()
+
+	client, err := globodns.New(nil, server.URL)
+	require.NoError(t, err)
+
+	_, err = offline.NewReader(client, store).ListDomains(context.TODO(), nil)
+	assert.EqualError(t, err, `globodns: unexpected HTTP status code: Code: 403 Body: {"error":"NOT AUTHORIZED"}`)
+}
@@ -0,0 +1,148 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offline
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+)
+
+var ErrSnapshotNotFound = errors.New("offline: snapshot not found")
+
+// Store keeps snapshots of domain and record listings as JSON files inside a
+// directory, one file per listing parameters.
+type Store struct {
+	dir string
+
+	// Now is used to timestamp snapshots, defaults to time.Now.
+	Now func() time.Time
+}
+
+type snapshot struct {
+	SavedAt time.Time         `json:"saved_at"`
+	Domains []globodns.Domain `json:"domains,omitempty"`
+	Records []globodns.Record `json:"records,omitempty"`
+}
+
+func Open(dir string) (*Store, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("offline: directory cannot be empty")
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &Store{dir: dir, Now: time.Now}, nil
+}
+
+func (s *Store) SaveDomains(p *globodns.ListDomainsParameters, domains []globodns.Domain) error {
+	return s.save(domainsFile(p), snapshot{Domains: domains})
+}
+
+// LoadDomains returns the domains saved for the given parameters and when they
+// were saved.
+func (s *Store) LoadDomains(p *globodns.ListDomainsParameters) ([]globodns.Domain, time.Time, error) {
+	snap, err := s.load(domainsFile(p))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	return snap.Domains, snap.SavedAt, nil
+}
+
+func (s *Store) SaveRecords(domainID int, p *globodns.ListRecordsParameters, records []globodns.Record) error {
+	return s.save(recordsFile(domainID, p), snapshot{Records: records})
+}
+
+// LoadRecords returns the records saved for the given domain and parameters
+// and when they were saved.
+func (s *Store) LoadRecords(domainID int, p *globodns.ListRecordsParameters) ([]globodns.Record, time.Time, error) {
+	snap, err := s.load(recordsFile(domainID, p))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	return snap.Records, snap.SavedAt, nil
+}
+
+func (s *Store) save(name string, snap snapshot) error {
+	snap.SavedAt = s.now()
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(s.dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	// NOTE: renaming is atomic, so readers never see a half written snapshot.
+	return os.Rename(f.Name(), filepath.Join(s.dir, name))
+}
+
+func (s *Store) load(name string) (*snapshot, error) {
+	data, err := ioutil.ReadFile(filepath.Join(s.dir, name))
+	if os.IsNotExist(err) {
+		return nil, ErrSnapshotNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var snap snapshot
+	if err = json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("offline: failed to decode snapshot %s: %w", name, err)
+	}
+
+	return &snap, nil
+}
+
+func (s *Store) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+
+	return s.Now()
+}
+
+func domainsFile(p *globodns.ListDomainsParameters) string {
+	return fmt.Sprintf("domains-%s.json", paramsHash(p.AsURLValues().Encode()))
+}
+
+func recordsFile(domainID int, p *globodns.ListRecordsParameters) string {
+	return fmt.Sprintf("records-%d-%s.json", domainID, paramsHash(p.AsURLValues().Encode()))
+}
+
+func paramsHash(query string) string {
+	if query == "" {
+		return "all"
+	}
+
+	sum := sha1.Sum([]byte(query))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,463 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+)
+
+// ErrQueued is returned by mutations that could not be sent to GloboDNS right
+// away and were persisted to be replayed later.
+var ErrQueued = errors.New("outbox: GloboDNS is unavailable, operation was queued")
+
+type Operation string
+
+const (
+	OperationCreate Operation = "create"
+	OperationUpdate Operation = "update"
+	OperationDelete Operation = "delete"
+)
+
+type State string
+
+const (
+	StatePending  State = "pending"
+	StateApplied  State = "applied"
+	StateConflict State = "conflict"
+	StateFailed   State = "failed"
+)
+
+type Entry struct {
+	ID         int64            `json:"id"`
+	Operation  Operation        `json:"operation"`
+	Record     globodns.Record  `json:"record"`
+	State      State            `json:"state"`
+	EnqueuedAt time.Time        `json:"enqueued_at"`
+	Attempts   int              `json:"attempts,omitempty"`
+	Error      string           `json:"error,omitempty"`
+	AppliedAt  *time.Time       `json:"applied_at,omitempty"`
+	Result     *globodns.Record `json:"result,omitempty"`
+}
+
+type Status struct {
+	Pending   int
+	Applied   int
+	Conflicts int
+	Failed    int
+	Entries   []Entry
+}
+
+type ReplayReport struct {
+	Applied   []Entry
+	Conflicts []Entry
+	Failed    []Entry
+	Remaining int
+}
+
+type journal struct {
+	NextID  int64   `json:"next_id"`
+	Entries []Entry `json:"entries"`
+}
+
+var _ globodns.RecordService = &Outbox{}
+
+// Outbox wraps a RecordService so that record mutations issued while
+// GloboDNS is unreachable are kept in a journal file, to be replayed in the
+// same order by Replay.
+//
+// Once there is any pending entry, new mutations are queued as well even if
+// GloboDNS is back, otherwise they could be applied out of order.
+type Outbox struct {
+	// Now is used to timestamp entries, defaults to time.Now.
+	Now func() time.Time
+
+	next globodns.RecordService
+	path string
+
+	mu      sync.Mutex
+	journal journal
+}
+
+func Open(next globodns.RecordService, path string) (*Outbox, error) {
+	if next == nil {
+		return nil, fmt.Errorf("outbox: record service cannot be nil")
+	}
+
+	if path == "" {
+		return nil, fmt.Errorf("outbox: journal path cannot be empty")
+	}
+
+	o := &Outbox{Now: time.Now, next: next, path: path, journal: journal{NextID: 1}}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return o, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &o.journal); err != nil {
+		return nil, fmt.Errorf("outbox: failed to decode journal %s: %w", path, err)
+	}
+
+	return o, nil
+}
+
+func (o *Outbox) Create(ctx context.Context, r globodns.Record) (*globodns.Record, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.hasPending() {
+		return nil, o.enqueue(OperationCreate, r)
+	}
+
+	created, err := o.next.Create(ctx, r)
+	if globodns.IsUnavailable(err) {
+		return nil, o.enqueue(OperationCreate, r)
+	}
+
+	return created, err
+}
+
+func (o *Outbox) Delete(ctx context.Context, recordID int) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.hasPending() {
+		return o.enqueue(OperationDelete, globodns.Record{ID: recordID})
+	}
+
+	err := o.next.Delete(ctx, recordID)
+	if globodns.IsUnavailable(err) {
+		return o.enqueue(OperationDelete, globodns.Record{ID: recordID})
+	}
+
+	return err
+}
+
+func (o *Outbox) Get(ctx context.Context, recordID int) (*globodns.Record, error) {
+	return o.next.Get(ctx, recordID)
+}
+
+func (o *Outbox) List(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) ([]globodns.Record, error) {
+	return o.next.List(ctx, domainID, p)
+}
+
+func (o *Outbox) ListPage(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) (*globodns.RecordPage, error) {
+	return o.next.ListPage(ctx, domainID, p)
+}
+
+func (o *Outbox) Update(ctx context.Context, r globodns.Record) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.hasPending() {
+		return o.enqueue(OperationUpdate, r)
+	}
+
+	err := o.next.Update(ctx, r)
+	if globodns.IsUnavailable(err) {
+		return o.enqueue(OperationUpdate, r)
+	}
+
+	return err
+}
+
+// Replay sends every pending entry to GloboDNS in the order they were queued.
+// It stops at the first entry that fails due to GloboDNS being unavailable,
+// leaving it and the following ones pending. Entries whose record has changed
+// since they were queued are not applied but marked as conflicting.
+func (o *Outbox) Replay(ctx context.Context) (*ReplayReport, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var report ReplayReport
+
+	for i := range o.journal.Entries {
+		e := &o.journal.Entries[i]
+		if e.State != StatePending {
+			continue
+		}
+
+		e.Attempts++
+
+		if err := o.apply(ctx, e); err != nil {
+			e.Error = err.Error()
+			report.Remaining = o.countPending()
+
+			if serr := o.save(); serr != nil {
+				return &report, serr
+			}
+
+			return &report, err
+		}
+
+		switch e.State {
+		case StateApplied:
+			report.Applied = append(report.Applied, *e)
+		case StateConflict:
+			report.Conflicts = append(report.Conflicts, *e)
+		case StateFailed:
+			report.Failed = append(report.Failed, *e)
+		}
+	}
+
+	return &report, o.save()
+}
+
+// Status returns the number of entries in each state along with a copy of
+// every entry in the journal.
+func (o *Outbox) Status() Status {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var s Status
+	for _, e := range o.journal.Entries {
+		switch e.State {
+		case StatePending:
+			s.Pending++
+		case StateApplied:
+			s.Applied++
+		case StateConflict:
+			s.Conflicts++
+		case StateFailed:
+			s.Failed++
+		}
+
+		s.Entries = append(s.Entries, e)
+	}
+
+	return s
+}
+
+// Discard removes an entry from the journal, e.g. after a conflict was
+// resolved by hand.
+func (o *Outbox) Discard(id int64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for i, e := range o.journal.Entries {
+		if e.ID == id {
+			o.journal.Entries = append(o.journal.Entries[:i], o.journal.Entries[i+1:]...)
+			return o.save()
+		}
+	}
+
+	return fmt.Errorf("outbox: entry %d not found", id)
+}
+
+// Prune removes every applied entry from the journal.
+func (o *Outbox) Prune() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var entries []Entry
+	for _, e := range o.journal.Entries {
+		if e.State != StateApplied {
+			entries = append(entries, e)
+		}
+	}
+
+	o.journal.Entries = entries
+	return o.save()
+}
+
+func (o *Outbox) apply(ctx context.Context, e *Entry) error {
+	reason, err := o.conflict(ctx, e)
+	if isTransient(ctx, err) {
+		return err
+	}
+
+	if err != nil {
+		o.finish(e, StateFailed, err.Error())
+		return nil
+	}
+
+	if reason != "" {
+		o.finish(e, StateConflict, reason)
+		return nil
+	}
+
+	switch e.Operation {
+	case OperationCreate:
+		e.Result, err = o.next.Create(ctx, e.Record)
+	case OperationUpdate:
+		err = o.next.Update(ctx, e.Record)
+	case OperationDelete:
+		err = o.next.Delete(ctx, e.Record.ID)
+		if isNotFound(err) {
+			err = nil
+		}
+	default:
+		err = fmt.Errorf("outbox: unknown operation %q", e.Operation)
+	}
+
+	if isTransient(ctx, err) {
+		return err
+	}
+
+	if err != nil {
+		o.finish(e, StateFailed, err.Error())
+		return nil
+	}
+
+	o.finish(e, StateApplied, "")
+	return nil
+}
+
+// conflict returns why the entry cannot be applied safely anymore, if so.
+func (o *Outbox) conflict(ctx context.Context, e *Entry) (string, error) {
+	switch e.Operation {
+	case OperationCreate:
+		rs, err := o.next.List(ctx, e.Record.DomainID, &globodns.ListRecordsParameters{Query: e.Record.Name})
+		if err != nil {
+			return "", err
+		}
+
+		for _, r := range rs {
+			if r.Name == e.Record.Name && strings.EqualFold(r.Type, e.Record.Type) && r.Content == e.Record.Content {
+				return fmt.Sprintf("record already exists with ID %d", r.ID), nil
+			}
+		}
+
+	case OperationUpdate, OperationDelete:
+		current, err := o.next.Get(ctx, e.Record.ID)
+		if isNotFound(err) {
+			if e.Operation == OperationDelete {
+				return "", nil
+			}
+
+			return "record was deleted", nil
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		// NOTE: relies on the local clock being reasonably in sync with
+		// the GloboDNS one.
+		if current.UpdatedAt != nil && current.UpdatedAt.After(e.EnqueuedAt) {
+			return fmt.Sprintf("record was changed at %s", current.UpdatedAt.Format(time.RFC3339)), nil
+		}
+	}
+
+	return "", nil
+}
+
+func (o *Outbox) finish(e *Entry, state State, reason string) {
+	e.State = state
+	e.Error = reason
+
+	if state == StateApplied {
+		now := o.now()
+		e.AppliedAt = &now
+	}
+}
+
+func (o *Outbox) enqueue(op Operation, r globodns.Record) error {
+	o.journal.Entries = append(o.journal.Entries, Entry{
+		ID:         o.journal.NextID,
+		Operation:  op,
+		Record:     r,
+		State:      StatePending,
+		EnqueuedAt: o.now(),
+	})
+	o.journal.NextID++
+
+	if err := o.save(); err != nil {
+		o.journal.Entries = o.journal.Entries[:len(o.journal.Entries)-1]
+		o.journal.NextID--
+		return fmt.Errorf("outbox: failed to persist %s operation: %w", op, err)
+	}
+
+	return ErrQueued
+}
+
+func (o *Outbox) hasPending() bool {
+	return o.countPending() > 0
+}
+
+func (o *Outbox) countPending() int {
+	var n int
+	for _, e := range o.journal.Entries {
+		if e.State == StatePending {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (o *Outbox) save() error {
+	sort.Slice(o.journal.Entries, func(i, j int) bool {
+		return o.journal.Entries[i].ID < o.journal.Entries[j].ID
+	})
+
+	data, err := json.MarshalIndent(o.journal, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir, name := filepath.Split(o.path)
+	if dir == "" {
+		dir = "."
+	}
+
+	f, err := ioutil.TempFile(dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), o.path)
+}
+
+func (o *Outbox) now() time.Time {
+	if o.Now == nil {
+		return time.Now()
+	}
+
+	return o.Now()
+}
+
+// isTransient reports whether the operation should be retried later rather
+// than being marked as failed.
+func isTransient(ctx context.Context, err error) bool {
+	return err != nil && (globodns.IsUnavailable(err) || ctx.Err() != nil)
+}
+
+func isNotFound(err error) bool {
+	var httpErr *globodns.HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound
+}
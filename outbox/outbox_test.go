@@ -0,0 +1,165 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package outbox_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/fake"
+	"github.com/tsuru/go-globodnsclient/outbox"
+)
+
+var errUnreachable = &url.Error{Op: "Post", URL: "http://globodns.example.com", Err: fmt.Errorf("connection refused")}
+
+func TestOutbox(t *testing.T) {
+	dir, err := ioutil.TempDir("", "globodns-outbox")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2021, 10, 29, 17, 43, 0, 0, time.UTC)
+	available := false
+
+	var applied []string
+
+	records := &fake.FakeRecordService{
+		FakeCreate: func(ctx context.Context, r globodns.Record) (*globodns.Record, error) {
+			if !available {
+				return nil, errUnreachable
+			}
+
+			applied = append(applied, "create "+r.Name)
+			r.ID = 1000
+			return &r, nil
+		},
+		FakeUpdate: func(ctx context.Context, r globodns.Record) error {
+			if !available {
+				return errUnreachable
+			}
+
+			applied = append(applied, fmt.Sprintf("update %d", r.ID))
+			return nil
+		},
+		FakeDelete: func(ctx context.Context, recordID int) error {
+			if !available {
+				return errUnreachable
+			}
+
+			applied = append(applied, fmt.Sprintf("delete %d", recordID))
+			return nil
+		},
+		FakeGet: func(ctx context.Context, recordID int) (*globodns.Record, error) {
+			if recordID == 404 {
+				return nil, &globodns.HTTPError{StatusCode: http.StatusNotFound}
+			}
+
+			if recordID == 20 {
+				return &globodns.Record{ID: 20, UpdatedAt: globodns.TimePointer(now.Add(time.Minute))}, nil
+			}
+
+			return &globodns.Record{ID: recordID, UpdatedAt: globodns.TimePointer(now.Add(-time.Hour))}, nil
+		},
+		FakeList: func(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) ([]globodns.Record, error) {
+			return nil, nil
+		},
+	}
+
+	path := filepath.Join(dir, "outbox.json")
+
+	o, err := outbox.Open(records, path)
+	require.NoError(t, err)
+	o.Now = func() time.Time { return now }
+
+	_, err = o.Create(context.TODO(), globodns.Record{DomainID: 100, Name: "www", Type: "A", Content: "169.196.100.100"})
+	assert.Equal(t, outbox.ErrQueued, err)
+
+	available = true
+
+	err = o.Update(context.TODO(), globodns.Record{ID: 10, DomainID: 100, Name: "mail", Type: "A", Content: "169.196.100.101"})
+	assert.Equal(t, outbox.ErrQueued, err, "should queue while there are pending entries")
+
+	err = o.Update(context.TODO(), globodns.Record{ID: 20, DomainID: 100, Name: "ftp", Type: "A", Content: "169.196.100.102"})
+	assert.Equal(t, outbox.ErrQueued, err)
+
+	err = o.Delete(context.TODO(), 404)
+	assert.Equal(t, outbox.ErrQueued, err)
+
+	err = o.Update(context.TODO(), globodns.Record{ID: 404})
+	assert.Equal(t, outbox.ErrQueued, err)
+
+	assert.Empty(t, applied)
+	assert.Equal(t, 5, o.Status().Pending)
+
+	// NOTE: reopens the journal to make sure the entries were persisted.
+	o, err = outbox.Open(records, path)
+	require.NoError(t, err)
+	o.Now = func() time.Time { return now }
+
+	report, err := o.Replay(context.TODO())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"create www", "update 10", "delete 404"}, applied)
+	assert.Len(t, report.Applied, 3)
+	assert.Equal(t, 1000, report.Applied[0].Result.ID)
+	assert.Equal(t, outbox.OperationDelete, report.Applied[2].Operation, "deleting a record that no longer exists is fine")
+	require.Len(t, report.Conflicts, 2)
+	assert.Equal(t, "record was changed at 2021-10-29T17:44:00Z", report.Conflicts[0].Error)
+	assert.Equal(t, "record was deleted", report.Conflicts[1].Error)
+	assert.Equal(t, 0, report.Remaining)
+
+	status := o.Status()
+	assert.Equal(t, 0, status.Pending)
+	assert.Equal(t, 3, status.Applied)
+	assert.Equal(t, 2, status.Conflicts)
+
+	require.NoError(t, o.Prune())
+	require.NoError(t, o.Discard(report.Conflicts[0].ID))
+	assert.Len(t, o.Status().Entries, 1)
+
+	err = o.Delete(context.TODO(), 30)
+	require.NoError(t, err, "should go straight to GloboDNS when nothing is pending")
+	assert.Equal(t, []string{"create www", "update 10", "delete 404", "delete 30"}, applied)
+}
+
+func TestOutbox_ReplayStopsWhenUnavailable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "globodns-outbox")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	records := &fake.FakeRecordService{
+		FakeDelete: func(ctx context.Context, recordID int) error {
+			return &globodns.HTTPError{StatusCode: http.StatusServiceUnavailable}
+		},
+		FakeGet: func(ctx context.Context, recordID int) (*globodns.Record, error) {
+			return &globodns.Record{ID: recordID}, nil
+		},
+	}
+
+	o, err := outbox.Open(records, filepath.Join(dir, "outbox.json"))
+	require.NoError(t, err)
+
+	assert.Equal(t, outbox.ErrQueued, o.Delete(context.TODO(), 1))
+	assert.Equal(t, outbox.ErrQueued, o.Delete(context.TODO(), 2))
+
+	report, err := o.Replay(context.TODO())
+	assert.Error(t, err)
+	assert.Equal(t, 2, report.Remaining)
+
+	status := o.Status()
+	assert.Equal(t, 2, status.Pending)
+	assert.Equal(t, 1, status.Entries[0].Attempts)
+	assert.Equal(t, 0, status.Entries[1].Attempts)
+}
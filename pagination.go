@@ -0,0 +1,135 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package globodns
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type Pagination struct {
+	Page       int
+	PerPage    int
+	Total      *int
+	TotalPages *int
+	NextPage   *int
+}
+
+func (p Pagination) HasNext() bool {
+	return p.NextPage != nil
+}
+
+type DomainPage struct {
+	Pagination
+	Domains []Domain
+}
+
+type RecordPage struct {
+	Pagination
+	Records []Record
+}
+
+// newPagination fills the page metadata from the request parameters and
+// overrides it with whatever the server sent back in the response headers,
+// e.g. X-Total-Count, X-Total-Pages, X-Next-Page or a Link header with
+// rel="next".
+func newPagination(page, perPage, count int, h http.Header) Pagination {
+	p := Pagination{Page: page, PerPage: perPage}
+
+	if n, ok := headerInt(h, "X-Page"); ok {
+		p.Page = n
+	}
+
+	if n, ok := headerInt(h, "X-Per-Page"); ok {
+		p.PerPage = n
+	}
+
+	if n, ok := headerInt(h, "X-Total-Count", "X-Total"); ok {
+		p.Total = &n
+	}
+
+	if n, ok := headerInt(h, "X-Total-Pages"); ok {
+		p.TotalPages = &n
+	}
+
+	if p.TotalPages == nil && p.Total != nil && p.PerPage > 0 {
+		pages := (*p.Total + p.PerPage - 1) / p.PerPage
+		p.TotalPages = &pages
+	}
+
+	if n, ok := headerInt(h, "X-Next-Page"); ok {
+		p.NextPage = &n
+	} else if n, ok := nextPageFromLink(h.Get("Link")); ok {
+		p.NextPage = &n
+	} else if p.TotalPages != nil && p.Page < *p.TotalPages {
+		next := p.Page + 1
+		p.NextPage = &next
+	}
+
+	if p.NextPage == nil && p.TotalPages == nil && p.PerPage > 0 && count == p.PerPage {
+		// NOTE: without any hint from the server, a full page means there
+		// might be more items ahead.
+		next := p.Page + 1
+		p.NextPage = &next
+	}
+
+	return p
+}
+
+func headerInt(h http.Header, keys ...string) (int, bool) {
+	for _, k := range keys {
+		v := strings.TrimSpace(h.Get(k))
+		if v == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			continue
+		}
+
+		return n, true
+	}
+
+	return 0, false
+}
+
+func nextPageFromLink(link string) (int, bool) {
+	for _, part := range strings.Split(link, ",") {
+		sections := strings.Split(part, ";")
+		if len(sections) < 2 {
+			continue
+		}
+
+		var isNext bool
+		for _, attr := range sections[1:] {
+			if strings.EqualFold(strings.TrimSpace(attr), `rel="next"`) {
+				isNext = true
+				break
+			}
+		}
+
+		if !isNext {
+			continue
+		}
+
+		raw := strings.Trim(strings.TrimSpace(sections[0]), "<>")
+		u, err := url.Parse(raw)
+		if err != nil {
+			return 0, false
+		}
+
+		n, err := strconv.Atoi(u.Query().Get("page"))
+		if err != nil {
+			return 0, false
+		}
+
+		return n, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,311 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package globodns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+type Operation string
+
+const (
+	OperationCreateDomain Operation = "domain.create"
+	OperationUpdateDomain Operation = "domain.update"
+	OperationDeleteDomain Operation = "domain.delete"
+	OperationCreateRecord Operation = "record.create"
+	OperationUpdateRecord Operation = "record.update"
+	OperationDeleteRecord Operation = "record.delete"
+)
+
+var ErrDenied = errors.New("globodns: operation denied by policy")
+
+// Identity is the caller on behalf of whom the client is changing GloboDNS.
+type Identity struct {
+	Name   string
+	Groups []string
+}
+
+type identityKey struct{}
+
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(Identity)
+	return id, ok
+}
+
+type PolicyRequest struct {
+	// Identity is taken from the context, nil if there is none.
+	Identity  *Identity
+	Operation Operation
+
+	// Domain is the domain being changed or the one holding the record.
+	Domain *Domain
+
+	// CurrentDomain is the domain as it is before an update.
+	CurrentDomain *Domain
+
+	// Record is the record being created, updated or deleted, nil for domain
+	// operations.
+	Record *Record
+
+	// CurrentRecord is the record as it is before an update.
+	CurrentRecord *Record
+}
+
+// Policy decides whether a mutation may be sent to GloboDNS. Returning an
+// error denies it; errors should wrap ErrDenied.
+type Policy interface {
+	Authorize(ctx context.Context, req PolicyRequest) error
+}
+
+type PolicyFunc func(ctx context.Context, req PolicyRequest) error
+
+func (f PolicyFunc) Authorize(ctx context.Context, req PolicyRequest) error {
+	return f(ctx, req)
+}
+
+type PolicyError struct {
+	Request PolicyRequest
+	Reason  string
+}
+
+func (e *PolicyError) Error() string {
+	who := "anonymous caller"
+	if e.Request.Identity != nil {
+		who = fmt.Sprintf("%q", e.Request.Identity.Name)
+	}
+
+	return fmt.Sprintf("%s: %s cannot %s: %s", ErrDenied, who, describeRequest(e.Request), e.Reason)
+}
+
+func (e *PolicyError) Is(target error) bool {
+	return target == ErrDenied
+}
+
+// SetPolicy makes every domain and record mutation consult p before being
+// sent. A nil policy allows everything.
+func (c *Client) SetPolicy(p Policy) {
+	c.policy = p
+}
+
+func (c *Client) authorize(ctx context.Context, req PolicyRequest) error {
+	if c.policy == nil {
+		return nil
+	}
+
+	if id, ok := IdentityFromContext(ctx); ok {
+		req.Identity = &id
+	}
+
+	return c.policy.Authorize(ctx, req)
+}
+
+// authorizeRecord checks a record mutation against the domain the record
+// is stored in, which for updates and deletions is the one of current, not
+// whatever domain the caller claims. Moving a record to another domain is
+// denied.
+func (c *Client) authorizeRecord(ctx context.Context, op Operation, r, current *Record) error {
+	if c.policy == nil {
+		return nil
+	}
+
+	domainID := r.DomainID
+	if current != nil {
+		domainID = current.DomainID
+	}
+
+	d, err := c.Domain.Get(ctx, domainID)
+	if err != nil {
+		return err
+	}
+
+	req := PolicyRequest{Operation: op, Domain: d, Record: r, CurrentRecord: current}
+
+	if current != nil && r.DomainID != 0 && r.DomainID != current.DomainID {
+		if id, ok := IdentityFromContext(ctx); ok {
+			req.Identity = &id
+		}
+
+		return &PolicyError{
+			Request: req,
+			Reason:  fmt.Sprintf("record %d belongs to domain %d, not %d", current.ID, current.DomainID, r.DomainID),
+		}
+	}
+
+	return c.authorize(ctx, req)
+}
+
+// PolicyRule allows the operations matching every one of its non-empty
+// fields.
+type PolicyRule struct {
+	// Identities holds identity names or groups.
+	Identities []string
+
+	Operations []Operation
+
+	// DomainIDs and Domains allow domains by ID or by name glob, as in
+	// path.Match.
+	DomainIDs []int
+	Domains   []string
+
+	// Names and Types restrict record operations to record names, as
+	// globs, and types. Rules setting either do not allow domain operations.
+	Names []string
+	Types []string
+}
+
+// RuleSet is a Policy allowing an operation if any of its rules matches it,
+// denying it otherwise.
+type RuleSet []PolicyRule
+
+var _ Policy = RuleSet{}
+
+func (rs RuleSet) Authorize(ctx context.Context, req PolicyRequest) error {
+	for _, rule := range rs {
+		if rule.match(req) {
+			return nil
+		}
+	}
+
+	return &PolicyError{Request: req, Reason: "no rule allows it"}
+}
+
+func (r PolicyRule) match(req PolicyRequest) bool {
+	if len(r.Identities) > 0 && (req.Identity == nil || !matchIdentity(r.Identities, *req.Identity)) {
+		return false
+	}
+
+	if len(r.Operations) > 0 && !containsOperation(r.Operations, req.Operation) {
+		return false
+	}
+
+	for _, d := range []*Domain{req.Domain, req.CurrentDomain} {
+		if d != nil && !r.matchDomain(*d) {
+			return false
+		}
+	}
+
+	if req.Record == nil {
+		return len(r.Names) == 0 && len(r.Types) == 0
+	}
+
+	for _, rec := range []*Record{req.Record, req.CurrentRecord} {
+		if rec != nil && !r.matchRecord(*rec) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r PolicyRule) matchDomain(d Domain) bool {
+	if len(r.DomainIDs) > 0 && !containsInt(r.DomainIDs, d.ID) {
+		return false
+	}
+
+	return len(r.Domains) == 0 || matchGlobs(r.Domains, strings.TrimSuffix(d.Name, "."))
+}
+
+func (r PolicyRule) matchRecord(rec Record) bool {
+	if len(r.Types) > 0 && !containsFold(r.Types, rec.Type) {
+		return false
+	}
+
+	name := rec.Name
+	if name == "" {
+		name = "@"
+	}
+
+	return len(r.Names) == 0 || matchGlobs(r.Names, name)
+}
+
+func matchIdentity(allowed []string, id Identity) bool {
+	for _, a := range allowed {
+		if a == id.Name {
+			return true
+		}
+
+		for _, g := range id.Groups {
+			if a == g {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func matchGlobs(globs []string, s string) bool {
+	for _, g := range globs {
+		if ok, _ := path.Match(strings.ToLower(g), strings.ToLower(s)); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsOperation(ops []Operation, op Operation) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsInt(is []int, i int) bool {
+	for _, n := range is {
+		if n == i {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsFold(ss []string, s string) bool {
+	for _, v := range ss {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func describeRequest(req PolicyRequest) string {
+	op := string(req.Operation)
+	if i := strings.Index(op, "."); i >= 0 {
+		op = op[i+1:]
+	}
+
+	var domain string
+	if req.Domain != nil {
+		domain = req.Domain.Name
+		if domain == "" {
+			domain = fmt.Sprintf("%d", req.Domain.ID)
+		}
+	}
+
+	if req.Record == nil {
+		return fmt.Sprintf("%s domain %s", op, domain)
+	}
+
+	name := req.Record.Name
+	if name == "" {
+		name = "@"
+	}
+
+	return fmt.Sprintf("%s %s record %s in %s", op, strings.ToUpper(req.Record.Type), name, domain)
+}
@@ -0,0 +1,234 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package globodns_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+)
+
+func TestRuleSet_Authorize(t *testing.T) {
+	rules := globodns.RuleSet{
+		{
+			Identities: []string{"team-a"},
+			Domains:    []string{"*.apps.example.com"},
+			Names:      []string{"app-*", "_acme-challenge.app-*"},
+			Types:      []string{"A", "CNAME", "TXT"},
+		},
+		{
+			Identities: []string{"dns-admins"},
+			Operations: []globodns.Operation{globodns.OperationCreateDomain, globodns.OperationDeleteDomain},
+		},
+		{
+			DomainIDs: []int{42},
+			Types:     []string{"TXT"},
+		},
+	}
+
+	domain := &globodns.Domain{ID: 1, Name: "prod.apps.example.com"}
+	teamA := &globodns.Identity{Name: "alice", Groups: []string{"team-a"}}
+
+	tests := map[string]struct {
+		request       globodns.PolicyRequest
+		expectedError string
+	}{
+		"record allowed by name glob and type": {
+			request: globodns.PolicyRequest{
+				Identity:  teamA,
+				Operation: globodns.OperationCreateRecord,
+				Domain:    domain,
+				Record:    &globodns.Record{Name: "app-web", Type: "cname"},
+			},
+		},
+
+		"record name not allowed": {
+			request: globodns.PolicyRequest{
+				Identity:  teamA,
+				Operation: globodns.OperationCreateRecord,
+				Domain:    domain,
+				Record:    &globodns.Record{Name: "www", Type: "A"},
+			},
+			expectedError: `globodns: operation denied by policy: "alice" cannot create A record www in prod.apps.example.com: no rule allows it`,
+		},
+
+		"record type not allowed": {
+			request: globodns.PolicyRequest{
+				Identity:  teamA,
+				Operation: globodns.OperationDeleteRecord,
+				Domain:    domain,
+				Record:    &globodns.Record{Name: "app-web", Type: "MX"},
+			},
+			expectedError: `globodns: operation denied by policy: "alice" cannot delete MX record app-web in prod.apps.example.com: no rule allows it`,
+		},
+
+		"renaming a record out of the allowed names": {
+			request: globodns.PolicyRequest{
+				Identity:      teamA,
+				Operation:     globodns.OperationUpdateRecord,
+				Domain:        domain,
+				Record:        &globodns.Record{Name: "app-web", Type: "A"},
+				CurrentRecord: &globodns.Record{Name: "@", Type: "A"},
+			},
+			expectedError: `globodns: operation denied by policy: "alice" cannot update A record app-web in prod.apps.example.com: no rule allows it`,
+		},
+
+		"domain not allowed": {
+			request: globodns.PolicyRequest{
+				Identity:  teamA,
+				Operation: globodns.OperationCreateRecord,
+				Domain:    &globodns.Domain{ID: 2, Name: "example.com"},
+				Record:    &globodns.Record{Name: "app-web", Type: "A"},
+			},
+			expectedError: `globodns: operation denied by policy: "alice" cannot create A record app-web in example.com: no rule allows it`,
+		},
+
+		"record rules do not allow domain operations": {
+			request: globodns.PolicyRequest{
+				Identity:  teamA,
+				Operation: globodns.OperationDeleteDomain,
+				Domain:    domain,
+			},
+			expectedError: `globodns: operation denied by policy: "alice" cannot delete domain prod.apps.example.com: no rule allows it`,
+		},
+
+		"domain operation allowed by identity name": {
+			request: globodns.PolicyRequest{
+				Identity:  &globodns.Identity{Name: "dns-admins"},
+				Operation: globodns.OperationCreateDomain,
+				Domain:    &globodns.Domain{Name: "new.example.com"},
+			},
+		},
+
+		"operation not allowed": {
+			request: globodns.PolicyRequest{
+				Identity:  &globodns.Identity{Name: "bob", Groups: []string{"dns-admins"}},
+				Operation: globodns.OperationUpdateDomain,
+				Domain:    domain,
+			},
+			expectedError: `globodns: operation denied by policy: "bob" cannot update domain prod.apps.example.com: no rule allows it`,
+		},
+
+		"anonymous caller allowed by domain ID": {
+			request: globodns.PolicyRequest{
+				Operation: globodns.OperationCreateRecord,
+				Domain:    &globodns.Domain{ID: 42, Name: "acme.example.com"},
+				Record:    &globodns.Record{Name: "_acme-challenge", Type: "TXT"},
+			},
+		},
+
+		"anonymous caller denied": {
+			request: globodns.PolicyRequest{
+				Operation: globodns.OperationCreateRecord,
+				Domain:    domain,
+				Record:    &globodns.Record{Name: "app-web", Type: "A"},
+			},
+			expectedError: `globodns: operation denied by policy: anonymous caller cannot create A record app-web in prod.apps.example.com: no rule allows it`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := rules.Authorize(context.TODO(), tt.request)
+			if tt.expectedError == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			assert.EqualError(t, err, tt.expectedError)
+			assert.ErrorIs(t, err, globodns.ErrDenied)
+		})
+	}
+}
+
+func TestClient_SetPolicy(t *testing.T) {
+	var calls []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+
+		switch r.Method + " " + r.URL.Path {
+		case "GET /domains/1.json":
+			fmt.Fprintf(w, `{"domain": {"id": 1, "name": "apps.example.com"}}`)
+		case "GET /records/10.json":
+			fmt.Fprintf(w, `{"a": {"id": 10, "domain_id": 1, "name": "app-web", "content": "169.196.100.100"}}`)
+		case "GET /records/11.json":
+			fmt.Fprintf(w, `{"a": {"id": 11, "domain_id": 1, "name": "www", "content": "169.196.100.101"}}`)
+		case "GET /domains/2.json":
+			fmt.Fprintf(w, `{"domain": {"id": 2, "name": "infra.example.com"}}`)
+		case "GET /records/99.json":
+			fmt.Fprintf(w, `{"a": {"id": 99, "domain_id": 2, "name": "app-db", "content": "169.196.100.200"}}`)
+		case "POST /domains/1/records.json":
+			fmt.Fprintf(w, `{"record": {"id": 12, "domain_id": 1, "name": "app-api", "type": "A", "content": "169.196.100.102"}}`)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	client, err := globodns.New(nil, server.URL)
+	require.NoError(t, err)
+
+	var identities []string
+	client.SetPolicy(globodns.PolicyFunc(func(ctx context.Context, req globodns.PolicyRequest) error {
+		if req.Identity != nil {
+			identities = append(identities, req.Identity.Name)
+		}
+
+		return globodns.RuleSet{{Identities: []string{"team-a"}, DomainIDs: []int{1}, Names: []string{"app-*"}}}.Authorize(ctx, req)
+	}))
+
+	ctx := globodns.WithIdentity(context.TODO(), globodns.Identity{Name: "alice", Groups: []string{"team-a"}})
+
+	id, ok := globodns.IdentityFromContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, "alice", id.Name)
+
+	_, err = client.Record.Create(ctx, globodns.Record{DomainID: 1, Name: "app-api", Type: "A", Content: "169.196.100.102"})
+	require.NoError(t, err)
+
+	err = client.Record.Update(ctx, globodns.Record{ID: 10, DomainID: 1, Name: "app-web", Type: "A", Content: "169.196.100.103"})
+	require.NoError(t, err)
+
+	err = client.Record.Delete(ctx, 11)
+	assert.ErrorIs(t, err, globodns.ErrDenied)
+
+	// record 99 is in domain 2, claiming the allowed domain 1 must not help
+	err = client.Record.Update(ctx, globodns.Record{ID: 99, DomainID: 1, Name: "app-db", Type: "A", Content: "169.196.100.201"})
+	assert.ErrorIs(t, err, globodns.ErrDenied)
+	assert.EqualError(t, err, `globodns: operation denied by policy: "alice" cannot update A record app-db in infra.example.com: record 99 belongs to domain 2, not 1`)
+
+	err = client.Record.Update(ctx, globodns.Record{ID: 99, Name: "app-db", Type: "A", Content: "169.196.100.201"})
+	assert.ErrorIs(t, err, globodns.ErrDenied)
+
+	err = client.Record.Delete(ctx, 99)
+	assert.ErrorIs(t, err, globodns.ErrDenied)
+
+	err = client.Record.Delete(context.TODO(), 10)
+	assert.ErrorIs(t, err, globodns.ErrDenied)
+
+	err = client.Domain.Delete(ctx, 1)
+	assert.ErrorIs(t, err, globodns.ErrDenied)
+
+	assert.Equal(t, []string{
+		"GET /domains/1.json", "POST /domains/1/records.json",
+		"GET /records/10.json", "GET /domains/1.json", "PUT /records/10.json",
+		"GET /records/11.json", "GET /domains/1.json",
+		"GET /records/99.json", "GET /domains/2.json",
+		"GET /records/99.json", "GET /domains/2.json",
+		"GET /records/99.json", "GET /domains/2.json",
+		"GET /records/10.json", "GET /domains/1.json",
+		"GET /domains/1.json",
+	}, calls)
+
+	assert.Equal(t, []string{"alice", "alice", "alice", "alice", "alice", "alice"}, identities)
+}
@@ -0,0 +1,138 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reconcile
+
+import (
+	"fmt"
+	"strings"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+)
+
+// DefaultProtected holds the records guarded by default: the SOA and the
+// apex NS and MX records.
+var DefaultProtected = []Protection{
+	{Type: "SOA"},
+	{Name: "@", Type: "NS"},
+	{Name: "@", Type: "MX"},
+}
+
+// Protection matches records which cannot be updated nor deleted. An empty
+// name or type matches any.
+type Protection struct {
+	Name string
+	Type string
+}
+
+func (p Protection) Match(r globodns.Record) bool {
+	if p.Name != "" && normalizeName(p.Name) != normalizeName(r.Name) {
+		return false
+	}
+
+	return p.Type == "" || strings.EqualFold(p.Type, r.Type)
+}
+
+func (p Protection) String() string {
+	name, rtype := "*", "*"
+	if p.Name != "" {
+		name = normalizeName(p.Name)
+	}
+
+	if p.Type != "" {
+		rtype = strings.ToUpper(p.Type)
+	}
+
+	return name + " " + rtype
+}
+
+// Limits are guard rails checked against a plan before applying it.
+type Limits struct {
+	// MaxDeletes is the maximum number of deletions in a single run, no
+	// limit if zero.
+	MaxDeletes int
+
+	// MaxChangePercent is the maximum number of records created, updated
+	// or deleted in a single run, as a percentage of the current records of
+	// the domain, no limit if zero. Domains without records are not limited,
+	// so they can be filled in the first place.
+	MaxChangePercent float64
+
+	// Protected lists the records which cannot be touched, DefaultProtected
+	// if nil. Use an empty slice to protect nothing.
+	Protected []Protection
+}
+
+type Violation struct {
+	Reason string
+	Change *Change
+}
+
+func (v Violation) String() string {
+	if v.Change == nil {
+		return v.Reason
+	}
+
+	return fmt.Sprintf("%s: %s", v.Reason, v.Change)
+}
+
+// LimitError is returned when a plan exceeds the limits, reporting every
+// violation found.
+type LimitError struct {
+	Plan       *Plan
+	Violations []Violation
+}
+
+func (e *LimitError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "reconcile: plan for domain %d exceeds safety limits (%d to create, %d to update, %d to delete):", e.Plan.DomainID, e.Plan.Count(ActionCreate), e.Plan.Count(ActionUpdate), e.Plan.Count(ActionDelete))
+
+	for _, v := range e.Violations {
+		sb.WriteString("\n  - ")
+		sb.WriteString(v.String())
+	}
+
+	return sb.String()
+}
+
+// Check returns a *LimitError if the plan exceeds any limit, nil otherwise.
+func (l Limits) Check(plan *Plan) error {
+	var violations []Violation
+
+	if deletes := plan.Count(ActionDelete); l.MaxDeletes > 0 && deletes > l.MaxDeletes {
+		violations = append(violations, Violation{Reason: fmt.Sprintf("%d records would be deleted, maximum is %d", deletes, l.MaxDeletes)})
+	}
+
+	if changed := plan.Count(ActionCreate) + plan.Count(ActionUpdate) + plan.Count(ActionDelete); l.MaxChangePercent > 0 && len(plan.Current) > 0 {
+		percent := float64(changed) * 100 / float64(len(plan.Current))
+		if percent > l.MaxChangePercent {
+			violations = append(violations, Violation{Reason: fmt.Sprintf("%.1f%% of the records would be changed (%d of %d), maximum is %.1f%%", percent, changed, len(plan.Current), l.MaxChangePercent)})
+		}
+	}
+
+	protected := l.Protected
+	if protected == nil {
+		protected = DefaultProtected
+	}
+
+	for i := range plan.Changes {
+		c := &plan.Changes[i]
+		if c.Current == nil {
+			continue
+		}
+
+		for _, p := range protected {
+			if p.Match(*c.Current) {
+				violations = append(violations, Violation{Reason: fmt.Sprintf("record is protected (%s)", p), Change: c})
+				break
+			}
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return &LimitError{Plan: plan, Violations: violations}
+}
@@ -0,0 +1,162 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reconcile_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/fake"
+	"github.com/tsuru/go-globodnsclient/reconcile"
+)
+
+func TestLimits_Check(t *testing.T) {
+	desired := []globodns.Record{
+		{Name: "www", Type: "A", Content: "169.196.100.100"},
+		{Name: "@", Type: "MX", Content: "mx"},
+	}
+
+	tests := map[string]struct {
+		limits        reconcile.Limits
+		desired       []globodns.Record
+		expectedError string
+	}{
+		"creations count as changes": {
+			limits: reconcile.Limits{MaxChangePercent: 50},
+			desired: []globodns.Record{
+				{Name: "www", Type: "A", Content: "169.196.100.100"},
+				{Name: "www", Type: "A", Content: "169.196.100.101"},
+				{Name: "@", Type: "MX", Content: "mail"},
+				{Name: "ftp", Type: "CNAME", Content: "www"},
+				{Name: "a", Type: "A", Content: "169.196.100.1"},
+				{Name: "b", Type: "A", Content: "169.196.100.2"},
+				{Name: "c", Type: "A", Content: "169.196.100.3"},
+			},
+			expectedError: `reconcile: plan for domain 100 exceeds safety limits (3 to create, 0 to update, 0 to delete):
+  - 60.0% of the records would be changed (3 of 5), maximum is 50.0%`,
+		},
+
+		"default protections": {
+			expectedError: `reconcile: plan for domain 100 exceeds safety limits (0 to create, 1 to update, 2 to delete):
+  - record is protected (@ MX): ~ @ MX 10 mail (ttl 3600) -> mx`,
+		},
+
+		"no protections": {
+			limits: reconcile.Limits{Protected: []reconcile.Protection{}},
+		},
+
+		"every limit exceeded": {
+			limits: reconcile.Limits{
+				MaxDeletes:       1,
+				MaxChangePercent: 50,
+				Protected:        []reconcile.Protection{{Name: "ftp"}},
+			},
+			expectedError: `reconcile: plan for domain 100 exceeds safety limits (0 to create, 1 to update, 2 to delete):
+  - 2 records would be deleted, maximum is 1
+  - 60.0% of the records would be changed (3 of 5), maximum is 50.0%
+  - record is protected (ftp *): - ftp CNAME www`,
+		},
+
+		"within limits": {
+			limits: reconcile.Limits{
+				MaxDeletes:       2,
+				MaxChangePercent: 60,
+				Protected:        []reconcile.Protection{{Type: "SOA"}},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			records := desired
+			if tt.desired != nil {
+				records = tt.desired
+			}
+
+			plan := reconcile.Diff(100, current, records, nil)
+
+			err := tt.limits.Check(plan)
+			if tt.expectedError == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			assert.EqualError(t, err, tt.expectedError)
+
+			var limitErr *reconcile.LimitError
+			require.ErrorAs(t, err, &limitErr)
+			assert.Same(t, plan, limitErr.Plan)
+		})
+	}
+}
+
+func TestReconciler_Limits(t *testing.T) {
+	var calls int
+
+	r := reconcile.Reconciler{
+		Records: &fake.FakeRecordService{
+			FakeList: func(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) ([]globodns.Record, error) {
+				return current, nil
+			},
+			FakeDelete: func(ctx context.Context, recordID int) error {
+				calls++
+				return nil
+			},
+		},
+		Limits: &reconcile.Limits{MaxDeletes: 2},
+	}
+
+	plan, err := r.Plan(context.TODO(), 100, nil)
+	require.NoError(t, err)
+
+	result, err := r.Apply(context.TODO(), plan)
+	assert.Error(t, err)
+	assert.Empty(t, result.Applied)
+	assert.Equal(t, 0, calls)
+
+	r.Override = true
+
+	result, err = r.Apply(context.TODO(), plan)
+	require.NoError(t, err)
+	assert.Len(t, result.Applied, 4)
+	assert.Equal(t, 4, calls)
+}
+
+func TestReconciler_DefaultProtections(t *testing.T) {
+	var calls int
+
+	r := reconcile.Reconciler{
+		Records: &fake.FakeRecordService{
+			FakeList: func(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) ([]globodns.Record, error) {
+				return current, nil
+			},
+			FakeDelete: func(ctx context.Context, recordID int) error {
+				calls++
+				return nil
+			},
+		},
+	}
+
+	plan, err := r.Plan(context.TODO(), 100, []globodns.Record{
+		{Name: "www", Type: "A", Content: "169.196.100.100"},
+		{Name: "www", Type: "A", Content: "169.196.100.101"},
+	})
+	require.NoError(t, err)
+
+	_, err = r.Apply(context.TODO(), plan)
+	assert.EqualError(t, err, `reconcile: plan for domain 100 exceeds safety limits (0 to create, 0 to update, 2 to delete):
+  - record is protected (@ MX): - @ MX 10 mail (ttl 3600)`)
+	assert.Equal(t, 0, calls, "apex records should be protected without any limits set")
+
+	r.Limits = &reconcile.Limits{Protected: []reconcile.Protection{}}
+
+	_, err = r.Apply(context.TODO(), plan)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,259 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reconcile
+
+import (
+	"fmt"
+	"strings"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+)
+
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+type Change struct {
+	Action Action
+
+	// Current is the record as it is in GloboDNS, nil for creations.
+	Current *globodns.Record
+
+	// Desired is the record as it should be, nil for deletions.
+	Desired *globodns.Record
+}
+
+// Record returns the record to be sent to GloboDNS.
+func (c Change) Record() globodns.Record {
+	if c.Desired != nil {
+		return *c.Desired
+	}
+
+	return *c.Current
+}
+
+func (c Change) String() string {
+	switch c.Action {
+	case ActionCreate:
+		return "+ " + formatRecord(*c.Desired)
+	case ActionDelete:
+		return "- " + formatRecord(*c.Current)
+	default:
+		return fmt.Sprintf("~ %s -> %s", formatRecord(*c.Current), formatData(*c.Desired))
+	}
+}
+
+type Plan struct {
+	DomainID int
+	Changes  []Change
+
+	// Current holds every record of the domain when the plan was made,
+	// including the ones not managed by the reconciler.
+	Current []globodns.Record
+}
+
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+func (p *Plan) Count(action Action) int {
+	var n int
+	for _, c := range p.Changes {
+		if c.Action == action {
+			n++
+		}
+	}
+
+	return n
+}
+
+// String returns a diff-like description of the plan, one change per line.
+func (p *Plan) String() string {
+	if p.Empty() {
+		return "No changes.\n"
+	}
+
+	var sb strings.Builder
+	for _, c := range p.Changes {
+		sb.WriteString(c.String())
+		sb.WriteString("\n")
+	}
+
+	fmt.Fprintf(&sb, "Plan: %d to create, %d to update, %d to delete.\n", p.Count(ActionCreate), p.Count(ActionUpdate), p.Count(ActionDelete))
+	return sb.String()
+}
+
+// Diff computes the changes needed to turn the current records of a domain
+// into the desired ones. Records are matched by name and type; among the
+// records sharing both, the ones with the same data are kept and the others
+// are updated in place as long as possible, before resorting to creations or
+// deletions.
+//
+// Only the given types are taken into account, or every type but SOA if
+// none. A nil TTL or priority in a desired record means any value is fine.
+func Diff(domainID int, current, desired []globodns.Record, types []string) *Plan {
+	plan := &Plan{DomainID: domainID, Current: current}
+
+	managed := func(r globodns.Record) bool {
+		t := strings.ToUpper(r.Type)
+		if len(types) == 0 {
+			return t != "SOA"
+		}
+
+		for _, mt := range types {
+			if strings.EqualFold(mt, t) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	var keys []string
+	currentByKey := make(map[string][]globodns.Record)
+	desiredByKey := make(map[string][]globodns.Record)
+
+	for _, r := range desired {
+		if !managed(r) {
+			continue
+		}
+
+		r.DomainID = domainID
+
+		k := key(r)
+		if _, ok := desiredByKey[k]; !ok {
+			keys = append(keys, k)
+		}
+
+		desiredByKey[k] = append(desiredByKey[k], r)
+	}
+
+	for _, r := range current {
+		if !managed(r) {
+			continue
+		}
+
+		k := key(r)
+		if _, ok := desiredByKey[k]; !ok {
+			if _, ok := currentByKey[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+
+		currentByKey[k] = append(currentByKey[k], r)
+	}
+
+	for _, k := range keys {
+		plan.Changes = append(plan.Changes, diffGroup(currentByKey[k], desiredByKey[k])...)
+	}
+
+	return plan
+}
+
+func diffGroup(current, desired []globodns.Record) []Change {
+	var changes []Change
+
+	var pendingDesired []globodns.Record
+	for _, d := range desired {
+		i := indexOf(current, func(c globodns.Record) bool { return sameData(c, d) })
+		if i < 0 {
+			pendingDesired = append(pendingDesired, d)
+			continue
+		}
+
+		c := current[i]
+		current = append(current[:i:i], current[i+1:]...)
+
+		if !sameTTL(c, d) {
+			changes = append(changes, update(c, d))
+		}
+	}
+
+	for len(pendingDesired) > 0 && len(current) > 0 {
+		changes = append(changes, update(current[0], pendingDesired[0]))
+		current, pendingDesired = current[1:], pendingDesired[1:]
+	}
+
+	for i := range pendingDesired {
+		d := pendingDesired[i]
+		changes = append(changes, Change{Action: ActionCreate, Desired: &d})
+	}
+
+	for i := range current {
+		c := current[i]
+		changes = append(changes, Change{Action: ActionDelete, Current: &c})
+	}
+
+	return changes
+}
+
+func update(current, desired globodns.Record) Change {
+	desired.ID = current.ID
+	return Change{Action: ActionUpdate, Current: &current, Desired: &desired}
+}
+
+func indexOf(rs []globodns.Record, match func(globodns.Record) bool) int {
+	for i, r := range rs {
+		if match(r) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func key(r globodns.Record) string {
+	return normalizeName(r.Name) + " " + strings.ToUpper(r.Type)
+}
+
+func normalizeName(name string) string {
+	if name == "" {
+		return "@"
+	}
+
+	return strings.ToLower(name)
+}
+
+func sameData(current, desired globodns.Record) bool {
+	if current.Content != desired.Content {
+		return false
+	}
+
+	return desired.Prio == nil || (current.Prio != nil && *current.Prio == *desired.Prio)
+}
+
+func sameTTL(current, desired globodns.Record) bool {
+	if desired.TTL == nil {
+		return true
+	}
+
+	c, d := current.GetTTL(), desired.GetTTL()
+	if c == nil || d == nil {
+		return globodns.StringValue(current.TTL) == *desired.TTL
+	}
+
+	return *c == *d
+}
+
+func formatRecord(r globodns.Record) string {
+	return fmt.Sprintf("%s %s %s", normalizeName(r.Name), strings.ToUpper(r.Type), formatData(r))
+}
+
+func formatData(r globodns.Record) string {
+	data := r.Content
+	if r.Prio != nil {
+		data = fmt.Sprintf("%d %s", *r.Prio, data)
+	}
+
+	if r.TTL != nil {
+		data = fmt.Sprintf("%s (ttl %s)", data, *r.TTL)
+	}
+
+	return data
+}
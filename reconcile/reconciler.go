@@ -0,0 +1,197 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+)
+
+// DefaultOrder creates and updates records before deleting the stale ones, so
+// names keep resolving during the run whenever possible. Deletions clashing
+// with a creation at the same name run before any other change anyway, see
+// Apply.
+var DefaultOrder = []Action{ActionCreate, ActionUpdate, ActionDelete}
+
+// Reconciler makes the records of a domain in GloboDNS match a desired state.
+type Reconciler struct {
+	Records globodns.RecordService
+
+	// Types limits the record types managed by the reconciler, every type
+	// but SOA if empty.
+	Types []string
+
+	// Order defines in which order the actions are applied, DefaultOrder if
+	// empty. Actions missing from it are not applied.
+	Order []Action
+
+	// ContinueOnError makes Apply go on after a change fails, instead of
+	// stopping right away.
+	ContinueOnError bool
+
+	// Limits are checked by Apply before changing anything. If nil, only
+	// the records in DefaultProtected are guarded; set Limits with an empty
+	// Protected to guard nothing.
+	Limits *Limits
+
+	// Override applies the plan even if it exceeds the limits, protected
+	// records included.
+	Override bool
+}
+
+type Result struct {
+	Applied []Change
+	Failed  []ChangeError
+}
+
+type ChangeError struct {
+	Change Change
+	Err    error
+}
+
+func (e ChangeError) Error() string {
+	return fmt.Sprintf("could not %s %s: %s", e.Change.Action, formatRecord(e.Change.Record()), e.Err)
+}
+
+func (e ChangeError) Unwrap() error {
+	return e.Err
+}
+
+// Plan lists the current records of the domain and computes the changes
+// needed to reach the desired ones.
+func (r *Reconciler) Plan(ctx context.Context, domainID int, desired []globodns.Record) (*Plan, error) {
+	if r.Records == nil {
+		return nil, fmt.Errorf("reconcile: record service cannot be nil")
+	}
+
+	current, err := r.Records.List(ctx, domainID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return Diff(domainID, current, desired, r.Types), nil
+}
+
+// Apply executes the changes of the plan following the configured order. A
+// plan exceeding the limits is not applied at all, unless overridden.
+//
+// Records which cannot coexist with a record being created at the same name,
+// i.e. a CNAME and a record of any other type, are deleted first, as
+// GloboDNS would reject the creation otherwise. That happens only if
+// deletions are in the order.
+func (r *Reconciler) Apply(ctx context.Context, plan *Plan) (*Result, error) {
+	if r.Records == nil {
+		return nil, fmt.Errorf("reconcile: record service cannot be nil")
+	}
+
+	if !r.Override {
+		limits := r.Limits
+		if limits == nil {
+			limits = &Limits{}
+		}
+
+		if err := limits.Check(plan); err != nil {
+			return &Result{}, err
+		}
+	}
+
+	order := r.Order
+	if len(order) == 0 {
+		order = DefaultOrder
+	}
+
+	var result Result
+
+	for _, c := range schedule(plan, order) {
+		if err := r.apply(ctx, c); err != nil {
+			result.Failed = append(result.Failed, ChangeError{Change: c, Err: err})
+
+			if !r.ContinueOnError {
+				return &result, fmt.Errorf("reconcile: %w", result.Failed[0])
+			}
+
+			continue
+		}
+
+		result.Applied = append(result.Applied, c)
+	}
+
+	if n := len(result.Failed); n > 0 {
+		return &result, fmt.Errorf("reconcile: %d of %d changes failed, first error: %w", n, len(plan.Changes), result.Failed[0])
+	}
+
+	return &result, nil
+}
+
+// schedule returns the changes of the plan in the order they are applied:
+// the deletions clashing with creations first, then the others by action.
+func schedule(plan *Plan, order []Action) []Change {
+	var changes []Change
+	early := make(map[int]bool)
+
+	if containsAction(order, ActionDelete) {
+		for i, c := range plan.Changes {
+			if c.Action == ActionDelete && clashes(*c.Current, plan.Changes) {
+				early[i] = true
+				changes = append(changes, c)
+			}
+		}
+	}
+
+	for _, action := range order {
+		for i, c := range plan.Changes {
+			if c.Action == action && !early[i] {
+				changes = append(changes, c)
+			}
+		}
+	}
+
+	return changes
+}
+
+// clashes tells whether current cannot coexist with any record created by
+// the changes.
+func clashes(current globodns.Record, changes []Change) bool {
+	for _, c := range changes {
+		if c.Action != ActionCreate || normalizeName(c.Desired.Name) != normalizeName(current.Name) {
+			continue
+		}
+
+		if strings.EqualFold(c.Desired.Type, "CNAME") || strings.EqualFold(current.Type, "CNAME") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsAction(actions []Action, action Action) bool {
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Reconciler) apply(ctx context.Context, c Change) error {
+	switch c.Action {
+	case ActionCreate:
+		_, err := r.Records.Create(ctx, *c.Desired)
+		return err
+
+	case ActionUpdate:
+		return r.Records.Update(ctx, *c.Desired)
+
+	case ActionDelete:
+		return r.Records.Delete(ctx, c.Current.ID)
+	}
+
+	return fmt.Errorf("unknown action %q", c.Action)
+}
@@ -0,0 +1,198 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reconcile_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/fake"
+	"github.com/tsuru/go-globodnsclient/reconcile"
+)
+
+var current = []globodns.Record{
+	{ID: 1, DomainID: 100, Name: "@", Type: "SOA", Content: "ns1.example.com. hostmaster.example.com. 2021102901 10800 3600 604800 3600"},
+	{ID: 2, DomainID: 100, Name: "www", Type: "A", Content: "169.196.100.100"},
+	{ID: 3, DomainID: 100, Name: "www", Type: "A", Content: "169.196.100.101"},
+	{ID: 4, DomainID: 100, Name: "@", Type: "MX", Content: "mail", Prio: globodns.IntPointer(10), TTL: globodns.StringPointer("3600")},
+	{ID: 5, DomainID: 100, Name: "ftp", Type: "CNAME", Content: "www"},
+}
+
+func TestDiff(t *testing.T) {
+	tests := map[string]struct {
+		desired  []globodns.Record
+		types    []string
+		expected string
+	}{
+		"nothing to change": {
+			desired: []globodns.Record{
+				{Name: "www", Type: "A", Content: "169.196.100.101"},
+				{Name: "WWW", Type: "a", Content: "169.196.100.100"},
+				{Name: "@", Type: "MX", Content: "mail"},
+				{Name: "ftp", Type: "CNAME", Content: "www"},
+			},
+			expected: "No changes.\n",
+		},
+
+		"creating, updating and deleting records": {
+			desired: []globodns.Record{
+				{Name: "www", Type: "A", Content: "169.196.100.100"},
+				{Name: "www", Type: "A", Content: "169.196.100.102"},
+				{Name: "@", Type: "MX", Content: "mail", Prio: globodns.IntPointer(10), TTL: globodns.StringPointer("60")},
+				{Name: "api", Type: "CNAME", Content: "www"},
+			},
+			expected: `~ www A 169.196.100.101 -> 169.196.100.102
+~ @ MX 10 mail (ttl 3600) -> 10 mail (ttl 60)
++ api CNAME www
+- ftp CNAME www
+Plan: 1 to create, 2 to update, 1 to delete.
+`,
+		},
+
+		"only managing some types": {
+			desired: []globodns.Record{{Name: "api", Type: "CNAME", Content: "www"}},
+			types:   []string{"CNAME"},
+			expected: `+ api CNAME www
+- ftp CNAME www
+Plan: 1 to create, 0 to update, 1 to delete.
+`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			plan := reconcile.Diff(100, current, tt.desired, tt.types)
+			assert.Equal(t, tt.expected, plan.String())
+		})
+	}
+}
+
+func TestReconciler(t *testing.T) {
+	desired := []globodns.Record{
+		{Name: "www", Type: "A", Content: "169.196.100.100"},
+		{Name: "www", Type: "A", Content: "169.196.100.102"},
+		{Name: "@", Type: "MX", Content: "mail"},
+		{Name: "api", Type: "CNAME", Content: "www"},
+	}
+
+	tests := map[string]struct {
+		reconciler    reconcile.Reconciler
+		failCreate    bool
+		expectedCalls []string
+		expectedError string
+	}{
+		"default order": {
+			expectedCalls: []string{"create api", "update 3", "delete 5"},
+		},
+
+		"custom order": {
+			reconciler:    reconcile.Reconciler{Order: []reconcile.Action{reconcile.ActionDelete, reconcile.ActionCreate}},
+			expectedCalls: []string{"delete 5", "create api"},
+		},
+
+		"stopping on error": {
+			failCreate:    true,
+			expectedCalls: []string{"create api"},
+			expectedError: "reconcile: could not create api CNAME www: some error",
+		},
+
+		"continuing on error": {
+			reconciler:    reconcile.Reconciler{ContinueOnError: true},
+			failCreate:    true,
+			expectedCalls: []string{"create api", "update 3", "delete 5"},
+			expectedError: "reconcile: 1 of 3 changes failed, first error: could not create api CNAME www: some error",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls []string
+
+			tt.reconciler.Records = &fake.FakeRecordService{
+				FakeList: func(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) ([]globodns.Record, error) {
+					return current, nil
+				},
+				FakeCreate: func(ctx context.Context, r globodns.Record) (*globodns.Record, error) {
+					calls = append(calls, "create "+r.Name)
+					assert.Equal(t, 100, r.DomainID)
+
+					if tt.failCreate {
+						return nil, fmt.Errorf("some error")
+					}
+
+					return &r, nil
+				},
+				FakeUpdate: func(ctx context.Context, r globodns.Record) error {
+					calls = append(calls, fmt.Sprintf("update %d", r.ID))
+					return nil
+				},
+				FakeDelete: func(ctx context.Context, recordID int) error {
+					calls = append(calls, fmt.Sprintf("delete %d", recordID))
+					return nil
+				},
+			}
+
+			plan, err := tt.reconciler.Plan(context.TODO(), 100, desired)
+			require.NoError(t, err)
+
+			result, err := tt.reconciler.Apply(context.TODO(), plan)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.expectedCalls, calls)
+			assert.Len(t, result.Applied, len(tt.expectedCalls)-len(result.Failed))
+		})
+	}
+}
+
+func TestReconciler_ReplacingByCNAME(t *testing.T) {
+	b := fake.NewBackend()
+	require.NoError(t, b.Seed(fake.State{
+		Domains: []globodns.Domain{{ID: 100, Name: "example.com"}},
+		Records: []globodns.Record{
+			{ID: 1, DomainID: 100, Name: "www", Type: "A", Content: "169.196.100.100"},
+			{ID: 2, DomainID: 100, Name: "www", Type: "AAAA", Content: "2001:db8::1"},
+			{ID: 3, DomainID: 100, Name: "api", Type: "A", Content: "169.196.100.101"},
+			{ID: 4, DomainID: 100, Name: "old", Type: "A", Content: "169.196.100.102"},
+		},
+	}))
+
+	r := reconcile.Reconciler{Records: b.Record}
+
+	plan, err := r.Plan(context.TODO(), 100, []globodns.Record{
+		{Name: "www", Type: "CNAME", Content: "lb.example.org."},
+		{Name: "api", Type: "A", Content: "169.196.100.101"},
+	})
+	require.NoError(t, err)
+
+	b.Recorder.Reset()
+
+	_, err = r.Apply(context.TODO(), plan)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Record.Delete", "Record.Delete", "Record.Create", "Record.Delete"}, methods(b.Recorder.Calls()))
+	b.Recorder.AssertCalledWith(t, "Record.Delete", 1, 4)
+
+	records, err := b.Record.List(context.TODO(), 100, nil)
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+}
+
+func methods(calls []fake.Call) []string {
+	var ms []string
+	for _, c := range calls {
+		ms = append(ms, c.Method)
+	}
+
+	return ms
+}
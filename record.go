@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -21,6 +22,7 @@ type Record struct {
 	Name      string     `json:"name"`
 	Type      string     `json:"type"`
 	TTL       *string    `json:"ttl,omitempty"`
+	Prio      *int       `json:"prio,omitempty"`
 	CreatedAt *time.Time `json:"created_at,omitempty"`
 	UpdatedAt *time.Time `json:"updated_at,omitempty"`
 	ID        int        `json:"id,omitempty"`
@@ -43,7 +45,9 @@ func (r *Record) GetTTL() *int {
 type RecordService interface {
 	Create(ctx context.Context, r Record) (*Record, error)
 	Delete(ctx context.Context, recordID int) error
+	Get(ctx context.Context, recordID int) (*Record, error)
 	List(ctx context.Context, domainID int, p *ListRecordsParameters) ([]Record, error)
+	ListPage(ctx context.Context, domainID int, p *ListRecordsParameters) (*RecordPage, error)
 	Update(ctx context.Context, r Record) error
 }
 
@@ -58,6 +62,10 @@ func (s *recordService) Create(ctx context.Context, r Record) (*Record, error) {
 		return nil, fmt.Errorf("globodns: domain ID cannot be negative")
 	}
 
+	if err := s.authorizeRecord(ctx, OperationCreateRecord, &r, nil); err != nil {
+		return nil, err
+	}
+
 	return s.create(ctx, r)
 }
 
@@ -100,6 +108,17 @@ func (s *recordService) Delete(ctx context.Context, recordID int) error {
 		return fmt.Errorf("globodns: record ID cannot be negative")
 	}
 
+	if s.policy != nil {
+		current, err := s.get(ctx, recordID)
+		if err != nil {
+			return err
+		}
+
+		if err = s.authorizeRecord(ctx, OperationDeleteRecord, current, nil); err != nil {
+			return err
+		}
+	}
+
 	return s.delete(ctx, recordID)
 }
 
@@ -115,6 +134,60 @@ func (s *recordService) delete(ctx context.Context, recordID int) error {
 	return err
 }
 
+func (s *recordService) Get(ctx context.Context, recordID int) (*Record, error) {
+	if recordID < 0 {
+		return nil, fmt.Errorf("globodns: record ID cannot be negative")
+	}
+
+	return s.get(ctx, recordID)
+}
+
+func (s *recordService) get(ctx context.Context, recordID int) (*Record, error) {
+	path := fmt.Sprintf("/records/%d.json", recordID)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", s.makeURL(path), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: the record comes wrapped by either its type (as in the listing) or
+	// the "record" key (as in the creation).
+	var got map[string]json.RawMessage
+
+	_, err = s.Do(req, &got)
+	if err != nil {
+		return nil, err
+	}
+
+	key, raw := "record", got["record"]
+	if raw == nil {
+		if len(got) != 1 {
+			keys := make([]string, 0, len(got))
+			for k := range got {
+				keys = append(keys, k)
+			}
+
+			sort.Strings(keys)
+			return nil, fmt.Errorf("globodns: record %d: expected the record under a single key, got %q", recordID, keys)
+		}
+
+		for k, v := range got {
+			key, raw = k, v
+		}
+	}
+
+	var record Record
+	if err = json.Unmarshal(raw, &record); err != nil {
+		return nil, err
+	}
+
+	if record.Type == "" && key != "record" {
+		record.Type = strings.ToUpper(key)
+	}
+
+	return &record, nil
+}
+
 type ListRecordsParameters struct {
 	Reverse *bool
 	Query   string
@@ -205,7 +278,37 @@ func (s *recordService) listAll(ctx context.Context, domainID int, p *ListRecord
 	return records, nil
 }
 
+func (s *recordService) ListPage(ctx context.Context, domainID int, p *ListRecordsParameters) (*RecordPage, error) {
+	if domainID < 0 {
+		return nil, fmt.Errorf("globodns: domain ID cannot be negative")
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	params := ListRecordsParameters{Page: 1}
+	if p != nil {
+		params = *p
+	}
+
+	if params.Page == 0 {
+		params.Page = 1
+	}
+
+	return s.listPage(ctx, domainID, &params)
+}
+
 func (s *recordService) list(ctx context.Context, domainID int, p *ListRecordsParameters) ([]Record, error) {
+	page, err := s.listPage(ctx, domainID, p)
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Records, nil
+}
+
+func (s *recordService) listPage(ctx context.Context, domainID int, p *ListRecordsParameters) (*RecordPage, error) {
 	path := fmt.Sprintf("/domains/%d/records.json?%s", domainID, p.AsURLValues().Encode())
 
 	req, err := http.NewRequestWithContext(ctx, "GET", s.makeURL(path), nil)
@@ -215,7 +318,7 @@ func (s *recordService) list(ctx context.Context, domainID int, p *ListRecordsPa
 
 	var got []map[string]Record
 
-	_, err = s.Do(req, &got)
+	res, err := s.Do(req, &got)
 	if err != nil {
 		return nil, err
 	}
@@ -228,7 +331,10 @@ func (s *recordService) list(ctx context.Context, domainID int, p *ListRecordsPa
 		}
 	}
 
-	return records, nil
+	return &RecordPage{
+		Pagination: newPagination(p.Page, p.PerPage, len(records), res.Header),
+		Records:    records,
+	}, nil
 }
 
 func (s *recordService) Update(ctx context.Context, r Record) error {
@@ -236,6 +342,17 @@ func (s *recordService) Update(ctx context.Context, r Record) error {
 		return fmt.Errorf("globodns: record ID cannot be negative")
 	}
 
+	if s.policy != nil {
+		current, err := s.get(ctx, r.ID)
+		if err != nil {
+			return err
+		}
+
+		if err = s.authorizeRecord(ctx, OperationUpdateRecord, &r, current); err != nil {
+			return err
+		}
+	}
+
 	return s.update(ctx, r)
 }
 
@@ -155,6 +155,89 @@ func TestClient_RecordList(t *testing.T) {
 	}
 }
 
+func TestClient_RecordListPage(t *testing.T) {
+	tests := map[string]struct {
+		handler       http.HandlerFunc
+		domainID      int
+		params        *globodns.ListRecordsParameters
+		expected      *globodns.RecordPage
+		expectedError string
+	}{
+		"domain id < 0": {
+			domainID:      -1,
+			expectedError: "globodns: domain ID cannot be negative",
+		},
+
+		"servers returns an error": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprintf(w, "some internal error")
+			},
+			expectedError: "globodns: unexpected HTTP status code: Code: 500 Body: some internal error",
+		},
+
+		"with pagination headers": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/domains/10/records.json", r.URL.Path)
+				assert.Equal(t, url.Values{"page": []string{"1"}, "per_page": []string{"2"}}, r.URL.Query())
+
+				w.Header().Set("X-Total", "3")
+				w.Header().Set("X-Next-Page", "2")
+				fmt.Fprintf(w, `[
+	{"a":   {"name": "www", "content": "169.196.100.100"}},
+	{"mx":  {"name": "@", "content": "mail", "ttl": "86400"}} ]`)
+			},
+			domainID: 10,
+			params:   &globodns.ListRecordsParameters{PerPage: 2},
+			expected: &globodns.RecordPage{
+				Pagination: globodns.Pagination{
+					Page:       1,
+					PerPage:    2,
+					Total:      globodns.IntPointer(3),
+					TotalPages: globodns.IntPointer(2),
+					NextPage:   globodns.IntPointer(2),
+				},
+				Records: []globodns.Record{
+					{Name: "www", Content: "169.196.100.100", Type: "A"},
+					{Name: "@", Content: "mail", TTL: globodns.StringPointer("86400"), Type: "MX"},
+				},
+			},
+		},
+
+		"without pagination headers and a partial page": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "7", r.URL.Query().Get("page"))
+				fmt.Fprintf(w, `[{"a":   {"name": "www", "content": "169.196.100.100"}}]`)
+			},
+			domainID: 10,
+			params:   &globodns.ListRecordsParameters{Page: 7, PerPage: 2},
+			expected: &globodns.RecordPage{
+				Pagination: globodns.Pagination{Page: 7, PerPage: 2},
+				Records:    []globodns.Record{{Name: "www", Content: "169.196.100.100", Type: "A"}},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			client, err := globodns.New(nil, server.URL)
+			require.NoError(t, err)
+
+			got, err := client.Record.ListPage(context.TODO(), tt.domainID, tt.params)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
 func TestClient_RecordCreate(t *testing.T) {
 	tests := map[string]struct {
 		handler       http.HandlerFunc
@@ -310,6 +393,97 @@ func TestClient_RecordDelete(t *testing.T) {
 	}
 }
 
+func TestClient_RecordGet(t *testing.T) {
+	tests := map[string]struct {
+		handler       http.HandlerFunc
+		recordID      int
+		expected      *globodns.Record
+		expectedError string
+	}{
+		"record id < 0": {
+			recordID:      -10,
+			expectedError: "globodns: record ID cannot be negative",
+		},
+
+		"when server returns error": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintf(w, `{"error":"NOT FOUND"}`)
+			},
+			recordID:      666,
+			expectedError: `globodns: unexpected HTTP status code: Code: 404 Body: {"error":"NOT FOUND"}`,
+		},
+
+		"getting a record wrapped by its type": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "GET", r.Method)
+				assert.Equal(t, "/records/1000.json", r.URL.Path)
+				fmt.Fprintf(w, `{"cname": {"id": 1000, "name": "www", "content": "example.com.", "domain_id": 100, "updated_at": "2021-01-01T00:00:00Z"}}`)
+			},
+			recordID: 1000,
+			expected: &globodns.Record{
+				ID:        1000,
+				Name:      "www",
+				Content:   "example.com.",
+				Type:      "CNAME",
+				DomainID:  100,
+				UpdatedAt: globodns.TimePointer(time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)),
+			},
+		},
+
+		"getting a record wrapped by record key": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"record": {"id": 1000, "name": "www", "type": "A", "content": "169.196.100.100", "domain_id": 100}}`)
+			},
+			recordID: 1000,
+			expected: &globodns.Record{ID: 1000, Name: "www", Content: "169.196.100.100", Type: "A", DomainID: 100},
+		},
+
+		"preferring the record key over other keys": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"a": {"id": 1, "name": "other"}, "record": {"id": 1000, "name": "www", "type": "A", "content": "169.196.100.100", "domain_id": 100}}`)
+			},
+			recordID: 1000,
+			expected: &globodns.Record{ID: 1000, Name: "www", Content: "169.196.100.100", Type: "A", DomainID: 100},
+		},
+
+		"record wrapped by many types": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"a": {"id": 1000, "name": "www"}, "cname": {"id": 1001, "name": "www"}}`)
+			},
+			recordID:      1000,
+			expectedError: `globodns: record 1000: expected the record under a single key, got ["a" "cname"]`,
+		},
+
+		"empty response": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{}`)
+			},
+			recordID:      1000,
+			expectedError: `globodns: record 1000: expected the record under a single key, got []`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			client, err := globodns.New(nil, server.URL)
+			require.NoError(t, err)
+
+			got, err := client.Record.Get(context.TODO(), tt.recordID)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
 func TestClient_RecordUpdate(t *testing.T) {
 	tests := map[string]struct {
 		handler       http.HandlerFunc
@@ -0,0 +1,356 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+)
+
+const (
+	DefaultPrefix = "_owner"
+
+	heritage = "go-globodnsclient"
+)
+
+var ErrNotOwned = errors.New("registry: record is not owned by this owner")
+
+var _ globodns.RecordService = &Registry{}
+
+// Registry keeps track of which records belong to an owner, in the same
+// fashion of the external-dns TXT registry: for each name and type owned, a
+// TXT record named "<prefix>-<type>.<name>" holds the owner ID.
+//
+// Records are only listed when owned, and changing or removing records not
+// owned fails with ErrNotOwned. Creating records is refused when there are
+// records with the same name and type which are not owned as well.
+type Registry struct {
+	next    globodns.RecordService
+	ownerID string
+	prefix  string
+}
+
+func New(next globodns.RecordService, ownerID string) (*Registry, error) {
+	return NewWithPrefix(next, ownerID, DefaultPrefix)
+}
+
+func NewWithPrefix(next globodns.RecordService, ownerID, prefix string) (*Registry, error) {
+	if next == nil {
+		return nil, fmt.Errorf("registry: record service cannot be nil")
+	}
+
+	if ownerID == "" || strings.ContainsAny(ownerID, `,"= `) {
+		return nil, fmt.Errorf("registry: invalid owner ID %q", ownerID)
+	}
+
+	if prefix == "" {
+		return nil, fmt.Errorf("registry: prefix cannot be empty")
+	}
+
+	return &Registry{next: next, ownerID: ownerID, prefix: strings.ToLower(prefix)}, nil
+}
+
+func (r *Registry) OwnerID() string {
+	return r.ownerID
+}
+
+func (r *Registry) Create(ctx context.Context, rec globodns.Record) (*globodns.Record, error) {
+	state, err := r.load(ctx, rec.DomainID)
+	if err != nil {
+		return nil, err
+	}
+
+	k := key(rec.Name, rec.Type)
+
+	var claim *globodns.Record
+	if !state.owned[k] {
+		if len(state.records[k]) > 0 || state.claimed[k] {
+			return nil, fmt.Errorf("%w: %s %s already exists", ErrNotOwned, strings.ToUpper(rec.Type), rec.Name)
+		}
+
+		if claim, err = r.claim(ctx, rec.DomainID, rec.Name, rec.Type); err != nil {
+			return nil, err
+		}
+	}
+
+	created, err := r.next.Create(ctx, rec)
+	if err != nil {
+		return nil, r.unclaim(ctx, claim, err)
+	}
+
+	return created, nil
+}
+
+func (r *Registry) Delete(ctx context.Context, recordID int) error {
+	current, state, err := r.ownedRecord(ctx, recordID)
+	if err != nil {
+		return err
+	}
+
+	if err = r.next.Delete(ctx, recordID); err != nil {
+		return err
+	}
+
+	return r.release(ctx, state, *current)
+}
+
+func (r *Registry) Get(ctx context.Context, recordID int) (*globodns.Record, error) {
+	current, _, err := r.ownedRecord(ctx, recordID)
+	return current, err
+}
+
+func (r *Registry) List(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) ([]globodns.Record, error) {
+	state, err := r.load(ctx, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	if p == nil {
+		return state.filter(state.all), nil
+	}
+
+	rs, err := r.next.List(ctx, domainID, p)
+	if err != nil {
+		return nil, err
+	}
+
+	return state.filter(rs), nil
+}
+
+// ListPage returns the owned records of the underlying page. Page, PerPage
+// and NextPage still refer to the underlying pages, so iterating with
+// NextPage visits every owned record, but pages may hold fewer than PerPage
+// records, even none. Total and TotalPages count records not owned as well,
+// thus they are cleared.
+func (r *Registry) ListPage(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) (*globodns.RecordPage, error) {
+	state, err := r.load(ctx, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := r.next.ListPage(ctx, domainID, p)
+	if err != nil {
+		return nil, err
+	}
+
+	page.Records = state.filter(page.Records)
+	page.Total, page.TotalPages = nil, nil
+	return page, nil
+}
+
+func (r *Registry) Update(ctx context.Context, rec globodns.Record) error {
+	current, state, err := r.ownedRecord(ctx, rec.ID)
+	if err != nil {
+		return err
+	}
+
+	if rec.DomainID == 0 {
+		rec.DomainID = current.DomainID
+	}
+
+	if rec.Type == "" {
+		rec.Type = current.Type
+	}
+
+	oldKey, newKey := key(current.Name, current.Type), key(rec.Name, rec.Type)
+	moving := oldKey != newKey
+
+	var claim *globodns.Record
+	if moving && !state.owned[newKey] {
+		if len(state.records[newKey]) > 0 || state.claimed[newKey] {
+			return fmt.Errorf("%w: %s %s already exists", ErrNotOwned, strings.ToUpper(rec.Type), rec.Name)
+		}
+
+		if claim, err = r.claim(ctx, rec.DomainID, rec.Name, rec.Type); err != nil {
+			return err
+		}
+	}
+
+	if err = r.next.Update(ctx, rec); err != nil {
+		return r.unclaim(ctx, claim, err)
+	}
+
+	if !moving {
+		return nil
+	}
+
+	return r.release(ctx, state, *current)
+}
+
+func (r *Registry) ownedRecord(ctx context.Context, recordID int) (*globodns.Record, *state, error) {
+	current, err := r.next.Get(ctx, recordID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	state, err := r.load(ctx, current.DomainID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !state.owned[key(current.Name, current.Type)] || state.isOwnership(*current) {
+		return nil, nil, fmt.Errorf("%w: record %d", ErrNotOwned, recordID)
+	}
+
+	return current, state, nil
+}
+
+// claim creates the ownership record for name and type.
+func (r *Registry) claim(ctx context.Context, domainID int, name, rtype string) (*globodns.Record, error) {
+	return r.next.Create(ctx, globodns.Record{
+		DomainID: domainID,
+		Name:     r.ownershipName(name, rtype),
+		Type:     "TXT",
+		Content:  r.ownershipContent(),
+	})
+}
+
+// unclaim removes the ownership record created by claim when the change it
+// was made for failed, so no orphan claim is left behind. It returns cause,
+// annotated if the removal fails as well.
+func (r *Registry) unclaim(ctx context.Context, claim *globodns.Record, cause error) error {
+	if claim == nil || claim.ID == 0 {
+		return cause
+	}
+
+	if err := r.next.Delete(ctx, claim.ID); err != nil {
+		return fmt.Errorf("%w (registry: could not remove ownership record %d: %v)", cause, claim.ID, err)
+	}
+
+	return cause
+}
+
+// release removes the ownership record of the given record if there is no
+// other record left with the same name and type.
+func (r *Registry) release(ctx context.Context, s *state, rec globodns.Record) error {
+	k := key(rec.Name, rec.Type)
+
+	for _, other := range s.records[k] {
+		if other.ID != rec.ID {
+			return nil
+		}
+	}
+
+	for _, o := range s.ownership[k] {
+		if err := r.next.Delete(ctx, o.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Registry) load(ctx context.Context, domainID int) (*state, error) {
+	all, err := r.next.List(ctx, domainID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &state{
+		registry:  r,
+		all:       all,
+		records:   make(map[string][]globodns.Record),
+		ownership: make(map[string][]globodns.Record),
+		owned:     make(map[string]bool),
+		claimed:   make(map[string]bool),
+	}
+
+	for _, rec := range all {
+		if name, rtype, ok := r.parseOwnershipName(rec); ok {
+			k := key(name, rtype)
+			if strings.Trim(rec.Content, `"`) == r.ownershipContent() {
+				s.owned[k] = true
+				s.ownership[k] = append(s.ownership[k], rec)
+			} else {
+				s.claimed[k] = true
+			}
+
+			continue
+		}
+
+		k := key(rec.Name, rec.Type)
+		s.records[k] = append(s.records[k], rec)
+	}
+
+	return s, nil
+}
+
+func (r *Registry) ownershipName(name, rtype string) string {
+	label := r.prefix + "-" + strings.ToLower(rtype)
+	if name == "" || name == "@" {
+		return label
+	}
+
+	return label + "." + name
+}
+
+func (r *Registry) parseOwnershipName(rec globodns.Record) (string, string, bool) {
+	if !strings.EqualFold(rec.Type, "TXT") {
+		return "", "", false
+	}
+
+	name := strings.ToLower(rec.Name)
+	if !strings.HasPrefix(name, r.prefix+"-") {
+		return "", "", false
+	}
+
+	label, owner := name, "@"
+	if i := strings.Index(name, "."); i >= 0 {
+		label, owner = name[:i], name[i+1:]
+	}
+
+	rtype := strings.TrimPrefix(label, r.prefix+"-")
+	if rtype == "" {
+		return "", "", false
+	}
+
+	return owner, rtype, true
+}
+
+func (r *Registry) ownershipContent() string {
+	return fmt.Sprintf("heritage=%s,owner=%s", heritage, r.ownerID)
+}
+
+type state struct {
+	registry *Registry
+
+	all       []globodns.Record
+	records   map[string][]globodns.Record
+	ownership map[string][]globodns.Record
+	owned     map[string]bool
+	claimed   map[string]bool
+}
+
+func (s *state) filter(rs []globodns.Record) []globodns.Record {
+	var owned []globodns.Record
+	for _, rec := range rs {
+		if s.isOwnership(rec) {
+			continue
+		}
+
+		if s.owned[key(rec.Name, rec.Type)] {
+			owned = append(owned, rec)
+		}
+	}
+
+	return owned
+}
+
+func (s *state) isOwnership(rec globodns.Record) bool {
+	_, _, ok := s.registry.parseOwnershipName(rec)
+	return ok
+}
+
+func key(name, rtype string) string {
+	if name == "" {
+		name = "@"
+	}
+
+	return strings.ToLower(name) + " " + strings.ToUpper(rtype)
+}
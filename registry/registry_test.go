@@ -0,0 +1,284 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package registry_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/fake"
+	"github.com/tsuru/go-globodnsclient/reconcile"
+	"github.com/tsuru/go-globodnsclient/registry"
+)
+
+type memoryRecords struct {
+	records []globodns.Record
+	nextID  int
+	calls   []string
+}
+
+func newMemoryRecords() *memoryRecords {
+	return &memoryRecords{
+		nextID: 100,
+		records: []globodns.Record{
+			{ID: 1, DomainID: 10, Name: "www", Type: "A", Content: "169.196.100.100"},
+			{ID: 2, DomainID: 10, Name: "_owner-a.www", Type: "TXT", Content: "heritage=go-globodnsclient,owner=tsuru"},
+			{ID: 3, DomainID: 10, Name: "api", Type: "A", Content: "169.196.100.101"},
+			{ID: 4, DomainID: 10, Name: "acme", Type: "TXT", Content: "challenge"},
+			{ID: 5, DomainID: 10, Name: "_owner-txt.acme", Type: "TXT", Content: "heritage=go-globodnsclient,owner=acme"},
+			{ID: 6, DomainID: 10, Name: "@", Type: "MX", Content: "mail"},
+			{ID: 7, DomainID: 10, Name: "_owner-mx", Type: "TXT", Content: `"heritage=go-globodnsclient,owner=tsuru"`},
+		},
+	}
+}
+
+func (m *memoryRecords) service() *fake.FakeRecordService {
+	return &fake.FakeRecordService{
+		FakeList: func(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) ([]globodns.Record, error) {
+			var rs []globodns.Record
+			for _, r := range m.records {
+				if r.DomainID == domainID && (p == nil || p.Query == "" || p.Query == r.Name) {
+					rs = append(rs, r)
+				}
+			}
+			return rs, nil
+		},
+		FakeGet: func(ctx context.Context, recordID int) (*globodns.Record, error) {
+			for _, r := range m.records {
+				if r.ID == recordID {
+					return &r, nil
+				}
+			}
+			return nil, fmt.Errorf("record not found")
+		},
+		FakeCreate: func(ctx context.Context, r globodns.Record) (*globodns.Record, error) {
+			m.calls = append(m.calls, fmt.Sprintf("create %s %s", r.Type, r.Name))
+			m.nextID++
+			r.ID = m.nextID
+			m.records = append(m.records, r)
+			return &r, nil
+		},
+		FakeUpdate: func(ctx context.Context, r globodns.Record) error {
+			m.calls = append(m.calls, fmt.Sprintf("update %d", r.ID))
+			for i := range m.records {
+				if m.records[i].ID == r.ID {
+					m.records[i] = r
+				}
+			}
+			return nil
+		},
+		FakeDelete: func(ctx context.Context, recordID int) error {
+			m.calls = append(m.calls, fmt.Sprintf("delete %d", recordID))
+			for i := range m.records {
+				if m.records[i].ID == recordID {
+					m.records = append(m.records[:i], m.records[i+1:]...)
+					break
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func TestNew(t *testing.T) {
+	_, err := registry.New(nil, "tsuru")
+	assert.EqualError(t, err, "registry: record service cannot be nil")
+
+	_, err = registry.New(&fake.FakeRecordService{}, "")
+	assert.EqualError(t, err, `registry: invalid owner ID ""`)
+
+	_, err = registry.New(&fake.FakeRecordService{}, "a,owner=b")
+	assert.EqualError(t, err, `registry: invalid owner ID "a,owner=b"`)
+
+	_, err = registry.NewWithPrefix(&fake.FakeRecordService{}, "tsuru", "")
+	assert.EqualError(t, err, "registry: prefix cannot be empty")
+}
+
+func TestRegistry_List(t *testing.T) {
+	m := newMemoryRecords()
+	r, err := registry.New(m.service(), "tsuru")
+	require.NoError(t, err)
+
+	rs, err := r.List(context.TODO(), 10, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []globodns.Record{
+		{ID: 1, DomainID: 10, Name: "www", Type: "A", Content: "169.196.100.100"},
+		{ID: 6, DomainID: 10, Name: "@", Type: "MX", Content: "mail"},
+	}, rs)
+
+	rs, err = r.List(context.TODO(), 10, &globodns.ListRecordsParameters{Query: "api"})
+	require.NoError(t, err)
+	assert.Empty(t, rs)
+
+	a, err := registry.New(m.service(), "acme")
+	require.NoError(t, err)
+
+	rs, err = a.List(context.TODO(), 10, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []globodns.Record{{ID: 4, DomainID: 10, Name: "acme", Type: "TXT", Content: "challenge"}}, rs)
+}
+
+func TestRegistry_ListPage(t *testing.T) {
+	m := newMemoryRecords()
+	svc := m.service()
+	svc.FakeListPage = func(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) (*globodns.RecordPage, error) {
+		total, pages, next := len(m.records), 2, 2
+		return &globodns.RecordPage{
+			Pagination: globodns.Pagination{Page: 1, PerPage: 4, Total: &total, TotalPages: &pages, NextPage: &next},
+			Records:    m.records[:4],
+		}, nil
+	}
+
+	r, err := registry.New(svc, "tsuru")
+	require.NoError(t, err)
+
+	page, err := r.ListPage(context.TODO(), 10, &globodns.ListRecordsParameters{Page: 1, PerPage: 4})
+	require.NoError(t, err)
+
+	next := 2
+	assert.Equal(t, &globodns.RecordPage{
+		Pagination: globodns.Pagination{Page: 1, PerPage: 4, NextPage: &next},
+		Records:    []globodns.Record{{ID: 1, DomainID: 10, Name: "www", Type: "A", Content: "169.196.100.100"}},
+	}, page)
+}
+
+func TestRegistry_Create(t *testing.T) {
+	tests := map[string]struct {
+		record        globodns.Record
+		expectedCalls []string
+		expectedError string
+	}{
+		"claiming a new name": {
+			record:        globodns.Record{DomainID: 10, Name: "blog", Type: "CNAME", Content: "www"},
+			expectedCalls: []string{"create TXT _owner-cname.blog", "create CNAME blog"},
+		},
+
+		"adding to an owned name": {
+			record:        globodns.Record{DomainID: 10, Name: "www", Type: "A", Content: "169.196.100.102"},
+			expectedCalls: []string{"create A www"},
+		},
+
+		"name created by someone else": {
+			record:        globodns.Record{DomainID: 10, Name: "api", Type: "A", Content: "169.196.100.102"},
+			expectedError: "registry: record is not owned by this owner: A api already exists",
+		},
+
+		"name owned by someone else": {
+			record:        globodns.Record{DomainID: 10, Name: "acme", Type: "TXT", Content: "other"},
+			expectedError: "registry: record is not owned by this owner: TXT acme already exists",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := newMemoryRecords()
+			r, err := registry.New(m.service(), "tsuru")
+			require.NoError(t, err)
+
+			_, err = r.Create(context.TODO(), tt.record)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				assert.ErrorIs(t, err, registry.ErrNotOwned)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.expectedCalls, m.calls)
+		})
+	}
+}
+
+func TestRegistry_RollsBackClaimOnFailure(t *testing.T) {
+	m := newMemoryRecords()
+	svc := m.service()
+
+	create := svc.FakeCreate
+	svc.FakeCreate = func(ctx context.Context, r globodns.Record) (*globodns.Record, error) {
+		if r.Type != "TXT" {
+			return nil, fmt.Errorf("create failed")
+		}
+		return create(ctx, r)
+	}
+	svc.FakeUpdate = func(ctx context.Context, r globodns.Record) error {
+		return fmt.Errorf("update failed")
+	}
+
+	r, err := registry.New(svc, "tsuru")
+	require.NoError(t, err)
+
+	_, err = r.Create(context.TODO(), globodns.Record{DomainID: 10, Name: "blog", Type: "CNAME", Content: "www"})
+	assert.EqualError(t, err, "create failed")
+
+	err = r.Update(context.TODO(), globodns.Record{ID: 1, Name: "web", Type: "A", Content: "169.196.100.100"})
+	assert.EqualError(t, err, "update failed")
+
+	assert.Equal(t, []string{
+		"create TXT _owner-cname.blog", "delete 101",
+		"create TXT _owner-a.web", "delete 102",
+	}, m.calls)
+
+	for _, rec := range m.records {
+		assert.NotContains(t, []string{"_owner-cname.blog", "_owner-a.web"}, rec.Name)
+	}
+}
+
+func TestRegistry_UpdateAndDelete(t *testing.T) {
+	m := newMemoryRecords()
+	r, err := registry.New(m.service(), "tsuru")
+	require.NoError(t, err)
+
+	err = r.Update(context.TODO(), globodns.Record{ID: 3, Name: "api", Type: "A", Content: "169.196.100.102"})
+	assert.ErrorIs(t, err, registry.ErrNotOwned)
+
+	err = r.Delete(context.TODO(), 4)
+	assert.ErrorIs(t, err, registry.ErrNotOwned)
+
+	err = r.Delete(context.TODO(), 2)
+	assert.ErrorIs(t, err, registry.ErrNotOwned)
+
+	_, err = r.Get(context.TODO(), 3)
+	assert.ErrorIs(t, err, registry.ErrNotOwned)
+
+	err = r.Update(context.TODO(), globodns.Record{ID: 1, Name: "web", Type: "A", Content: "169.196.100.100"})
+	require.NoError(t, err)
+
+	err = r.Delete(context.TODO(), 6)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"create TXT _owner-a.web", "update 1", "delete 2", "delete 6", "delete 7"}, m.calls)
+
+	rs, err := r.List(context.TODO(), 10, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []globodns.Record{{ID: 1, DomainID: 10, Name: "web", Type: "A", Content: "169.196.100.100"}}, rs)
+}
+
+func TestRegistry_Reconciler(t *testing.T) {
+	m := newMemoryRecords()
+	r, err := registry.New(m.service(), "tsuru")
+	require.NoError(t, err)
+
+	// NOTE: the apex MX is owned here, so it may go away.
+	rec := reconcile.Reconciler{Records: r, Limits: &reconcile.Limits{Protected: []reconcile.Protection{}}}
+
+	plan, err := rec.Plan(context.TODO(), 10, []globodns.Record{
+		{Name: "www", Type: "A", Content: "169.196.100.100"},
+		{Name: "app", Type: "A", Content: "169.196.100.103"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `+ app A 169.196.100.103
+- @ MX mail
+Plan: 1 to create, 0 to update, 1 to delete.
+`, plan.String())
+
+	_, err = rec.Apply(context.TODO(), plan)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"create TXT _owner-a.app", "create A app", "delete 6", "delete 7"}, m.calls)
+}
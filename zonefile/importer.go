@@ -0,0 +1,104 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zonefile
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+)
+
+type Importer struct {
+	Records globodns.RecordService
+
+	// DryRun reports what would be created without changing GloboDNS.
+	DryRun bool
+
+	// SkipExisting skips the records that already exist in the domain, or
+	// earlier in the input, with the same name, type and content.
+	SkipExisting bool
+}
+
+type ImportReport struct {
+	Created []globodns.Record
+	Skipped []globodns.Record
+	Failed  []ImportError
+}
+
+type ImportError struct {
+	Record globodns.Record
+	Err    error
+}
+
+func (e ImportError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.Record.Type, e.Record.Name, e.Err)
+}
+
+// Import creates the records into domain d. SOA records are always skipped,
+// as GloboDNS manages them along with the domain itself. It goes through every
+// record even if some fail, returning an error at the end if so.
+func (i *Importer) Import(ctx context.Context, d globodns.Domain, records []globodns.Record) (*ImportReport, error) {
+	if i.Records == nil {
+		return nil, fmt.Errorf("zonefile: record service cannot be nil")
+	}
+
+	existing := make(map[string]bool)
+	if i.SkipExisting {
+		rs, err := i.Records.List(ctx, d.ID, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range rs {
+			existing[recordKey(r)] = true
+		}
+	}
+
+	var report ImportReport
+
+	for _, r := range records {
+		r.ID = 0
+		r.DomainID = d.ID
+
+		if strings.EqualFold(r.Type, "SOA") || existing[recordKey(r)] {
+			report.Skipped = append(report.Skipped, r)
+			continue
+		}
+
+		if i.SkipExisting {
+			existing[recordKey(r)] = true
+		}
+
+		if i.DryRun {
+			report.Created = append(report.Created, r)
+			continue
+		}
+
+		created, err := i.Records.Create(ctx, r)
+		if err != nil {
+			report.Failed = append(report.Failed, ImportError{Record: r, Err: err})
+			continue
+		}
+
+		report.Created = append(report.Created, *created)
+	}
+
+	if n := len(report.Failed); n > 0 {
+		return &report, fmt.Errorf("zonefile: failed to import %d of %d records into %s: %w", n, len(records), d.Name, report.Failed[0])
+	}
+
+	return &report, nil
+}
+
+func recordKey(r globodns.Record) string {
+	var prio string
+	if r.Prio != nil {
+		prio = fmt.Sprint(*r.Prio)
+	}
+
+	return strings.Join([]string{strings.ToLower(r.Name), strings.ToUpper(r.Type), prio, r.Content}, "\x00")
+}
@@ -0,0 +1,154 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zonefile_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/fake"
+	"github.com/tsuru/go-globodnsclient/zonefile"
+)
+
+func TestImporter_Import(t *testing.T) {
+	domain := globodns.Domain{ID: 100, Name: "example.com"}
+
+	records := []globodns.Record{
+		{Name: "@", Type: "SOA", Content: "ns1.example.com. hostmaster.example.com. 2021102901 10800 3600 604800 3600"},
+		{Name: "www", Type: "A", Content: "169.196.100.100"},
+		{Name: "@", Type: "MX", Content: "mail", Prio: globodns.IntPointer(10)},
+		{Name: "broken", Type: "A", Content: "not an IP"},
+	}
+
+	tests := map[string]struct {
+		importer      zonefile.Importer
+		expected      *zonefile.ImportReport
+		expectedCalls []string
+		expectedError string
+	}{
+		"dry run": {
+			importer: zonefile.Importer{DryRun: true, SkipExisting: true},
+			expected: &zonefile.ImportReport{
+				Created: []globodns.Record{
+					{DomainID: 100, Name: "@", Type: "MX", Content: "mail", Prio: globodns.IntPointer(10)},
+					{DomainID: 100, Name: "broken", Type: "A", Content: "not an IP"},
+				},
+				Skipped: []globodns.Record{
+					{DomainID: 100, Name: "@", Type: "SOA", Content: "ns1.example.com. hostmaster.example.com. 2021102901 10800 3600 604800 3600"},
+					{DomainID: 100, Name: "www", Type: "A", Content: "169.196.100.100"},
+				},
+			},
+		},
+
+		"creating every record": {
+			expected: &zonefile.ImportReport{
+				Created: []globodns.Record{
+					{ID: 1, DomainID: 100, Name: "www", Type: "A", Content: "169.196.100.100"},
+					{ID: 1, DomainID: 100, Name: "@", Type: "MX", Content: "mail", Prio: globodns.IntPointer(10)},
+				},
+				Skipped: []globodns.Record{
+					{DomainID: 100, Name: "@", Type: "SOA", Content: "ns1.example.com. hostmaster.example.com. 2021102901 10800 3600 604800 3600"},
+				},
+				Failed: []zonefile.ImportError{
+					{Record: globodns.Record{DomainID: 100, Name: "broken", Type: "A", Content: "not an IP"}, Err: fmt.Errorf("invalid content")},
+				},
+			},
+			expectedCalls: []string{"www", "@", "broken"},
+			expectedError: "zonefile: failed to import 1 of 4 records into example.com: A broken: invalid content",
+		},
+
+		"skipping existing records": {
+			importer: zonefile.Importer{SkipExisting: true},
+			expected: &zonefile.ImportReport{
+				Created: []globodns.Record{
+					{ID: 1, DomainID: 100, Name: "@", Type: "MX", Content: "mail", Prio: globodns.IntPointer(10)},
+				},
+				Skipped: []globodns.Record{
+					{DomainID: 100, Name: "@", Type: "SOA", Content: "ns1.example.com. hostmaster.example.com. 2021102901 10800 3600 604800 3600"},
+					{DomainID: 100, Name: "www", Type: "A", Content: "169.196.100.100"},
+				},
+				Failed: []zonefile.ImportError{
+					{Record: globodns.Record{DomainID: 100, Name: "broken", Type: "A", Content: "not an IP"}, Err: fmt.Errorf("invalid content")},
+				},
+			},
+			expectedCalls: []string{"@", "broken"},
+			expectedError: "zonefile: failed to import 1 of 4 records into example.com: A broken: invalid content",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls []string
+
+			tt.importer.Records = &fake.FakeRecordService{
+				FakeList: func(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) ([]globodns.Record, error) {
+					assert.Equal(t, 100, domainID)
+					return []globodns.Record{{ID: 10, DomainID: 100, Name: "www", Type: "A", Content: "169.196.100.100"}}, nil
+				},
+				FakeCreate: func(ctx context.Context, r globodns.Record) (*globodns.Record, error) {
+					calls = append(calls, r.Name)
+
+					if r.Content == "not an IP" {
+						return nil, fmt.Errorf("invalid content")
+					}
+
+					r.ID = 1
+					return &r, nil
+				},
+			}
+
+			got, err := tt.importer.Import(context.TODO(), domain, records)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.expected, got)
+			assert.Equal(t, tt.expectedCalls, calls)
+		})
+	}
+}
+
+func TestImporter_ImportSkipsDuplicates(t *testing.T) {
+	var calls []string
+
+	importer := zonefile.Importer{
+		SkipExisting: true,
+		Records: &fake.FakeRecordService{
+			FakeList: func(ctx context.Context, domainID int, p *globodns.ListRecordsParameters) ([]globodns.Record, error) {
+				return nil, nil
+			},
+			FakeCreate: func(ctx context.Context, r globodns.Record) (*globodns.Record, error) {
+				calls = append(calls, r.Name)
+				r.ID = len(calls)
+				return &r, nil
+			},
+		},
+	}
+
+	got, err := importer.Import(context.TODO(), globodns.Domain{ID: 100, Name: "example.com"}, []globodns.Record{
+		{Name: "www", Type: "A", Content: "169.196.100.100"},
+		{Name: "WWW", Type: "a", Content: "169.196.100.100"},
+		{Name: "www", Type: "A", Content: "169.196.100.101"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, &zonefile.ImportReport{
+		Created: []globodns.Record{
+			{ID: 1, DomainID: 100, Name: "www", Type: "A", Content: "169.196.100.100"},
+			{ID: 2, DomainID: 100, Name: "www", Type: "A", Content: "169.196.100.101"},
+		},
+		Skipped: []globodns.Record{
+			{DomainID: 100, Name: "WWW", Type: "a", Content: "169.196.100.100"},
+		},
+	}, got)
+	assert.Equal(t, []string{"www", "www"}, calls)
+}
@@ -0,0 +1,658 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zonefile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+)
+
+// maxIncludeDepth limits nested $INCLUDE directives, mostly to break loops.
+const maxIncludeDepth = 10
+
+// Parser reads master files (RFC 1035 section 5) turning their resource
+// records into GloboDNS records of a domain.
+type Parser struct {
+	Domain globodns.Domain
+
+	// Open is used to read the files referenced by $INCLUDE directives,
+	// defaults to os.Open.
+	Open func(name string) (io.ReadCloser, error)
+}
+
+// Parse parses a master file of domain d. Files referenced by $INCLUDE are
+// resolved relative to the current directory.
+func Parse(r io.Reader, d globodns.Domain) ([]globodns.Record, error) {
+	p := &Parser{Domain: d}
+	return p.Parse(r, "")
+}
+
+// ParseFile parses the master file at path. Files referenced by $INCLUDE are
+// resolved relative to its directory.
+func ParseFile(path string, d globodns.Domain) ([]globodns.Record, error) {
+	p := &Parser{Domain: d}
+
+	f, err := p.open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return p.Parse(f, filepath.Dir(path))
+}
+
+// Parse parses a master file whose $INCLUDE directives are resolved relative
+// to dir.
+func (p *Parser) Parse(r io.Reader, dir string) ([]globodns.Record, error) {
+	if p.Domain.Name == "" {
+		return nil, fmt.Errorf("zonefile: domain name cannot be empty")
+	}
+
+	s := &parseState{
+		parser: p,
+		zone:   strings.ToLower(fqdn(p.Domain.Name)),
+		dir:    dir,
+	}
+	s.origin = s.zone
+
+	if err := s.parse(r, "", 0); err != nil {
+		return nil, err
+	}
+
+	return s.records, nil
+}
+
+func (p *Parser) open(name string) (io.ReadCloser, error) {
+	if p.Open != nil {
+		return p.Open(name)
+	}
+
+	return os.Open(name)
+}
+
+type parseState struct {
+	parser *Parser
+
+	zone       string
+	origin     string
+	dir        string
+	defaultTTL *int
+	lastOwner  string
+	records    []globodns.Record
+}
+
+func (s *parseState) parse(r io.Reader, file string, depth int) error {
+	l := newLexer(r)
+
+	for {
+		entry, err := l.next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return s.errorf(file, l.line, "%s", err)
+		}
+
+		if err = s.parseEntry(entry, file, depth); err != nil {
+			return s.errorf(file, entry.line, "%s", err)
+		}
+	}
+}
+
+func (s *parseState) errorf(file string, line int, format string, args ...interface{}) error {
+	if file == "" {
+		file = "<input>"
+	}
+
+	return fmt.Errorf("zonefile: %s:%d: %s", file, line, fmt.Sprintf(format, args...))
+}
+
+func (s *parseState) parseEntry(e *entry, file string, depth int) error {
+	tokens := e.tokens
+
+	if !e.blankOwner && len(tokens) > 0 && !tokens[0].quoted && strings.HasPrefix(tokens[0].value, "$") {
+		return s.parseDirective(tokens, depth)
+	}
+
+	owner := s.lastOwner
+	if !e.blankOwner {
+		if len(tokens) == 0 {
+			return fmt.Errorf("missing owner name")
+		}
+
+		name, err := s.absolute(tokens[0].value)
+		if err != nil {
+			return err
+		}
+
+		owner = name
+		tokens = tokens[1:]
+	}
+
+	if owner == "" {
+		return fmt.Errorf("missing owner name")
+	}
+
+	s.lastOwner = owner
+
+	var ttl *int
+	for len(tokens) > 0 && !tokens[0].quoted {
+		v := tokens[0].value
+		if strings.EqualFold(v, "IN") {
+			tokens = tokens[1:]
+			continue
+		}
+
+		if isClass(v) {
+			return fmt.Errorf("unsupported class %s", strings.ToUpper(v))
+		}
+
+		n, err := parseTTL(v)
+		if err != nil {
+			break
+		}
+
+		ttl = &n
+		tokens = tokens[1:]
+	}
+
+	if len(tokens) == 0 {
+		return fmt.Errorf("missing record type")
+	}
+
+	rtype := strings.ToUpper(tokens[0].value)
+	rdata := tokens[1:]
+
+	name, err := s.relative(owner)
+	if err != nil {
+		return err
+	}
+
+	record := globodns.Record{Name: name, Type: rtype, DomainID: s.parser.Domain.ID}
+
+	if err = s.setContent(&record, rdata); err != nil {
+		return err
+	}
+
+	if ttl == nil && s.defaultTTL != nil {
+		if domainTTL := s.parser.Domain.GetTTL(); domainTTL == nil || *domainTTL != *s.defaultTTL {
+			ttl = s.defaultTTL
+		}
+	}
+
+	if ttl != nil {
+		record.TTL = globodns.StringPointer(strconv.Itoa(*ttl))
+	}
+
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *parseState) parseDirective(tokens []token, depth int) error {
+	switch directive := strings.ToUpper(tokens[0].value); directive {
+	case "$ORIGIN":
+		if len(tokens) != 2 {
+			return fmt.Errorf("$ORIGIN takes exactly one argument")
+		}
+
+		origin, err := s.absolute(tokens[1].value)
+		if err != nil {
+			return err
+		}
+
+		s.origin = origin
+
+	case "$TTL":
+		if len(tokens) != 2 {
+			return fmt.Errorf("$TTL takes exactly one argument")
+		}
+
+		ttl, err := parseTTL(tokens[1].value)
+		if err != nil {
+			return err
+		}
+
+		s.defaultTTL = &ttl
+
+	case "$INCLUDE":
+		if len(tokens) < 2 || len(tokens) > 3 {
+			return fmt.Errorf("$INCLUDE takes a file name and an optional origin")
+		}
+
+		if depth >= maxIncludeDepth {
+			return fmt.Errorf("too many nested $INCLUDE directives")
+		}
+
+		return s.include(tokens[1:], depth)
+
+	default:
+		return fmt.Errorf("unsupported directive %s", directive)
+	}
+
+	return nil
+}
+
+func (s *parseState) include(args []token, depth int) error {
+	name := args[0].value
+	if !filepath.IsAbs(name) && s.dir != "" {
+		name = filepath.Join(s.dir, name)
+	}
+
+	origin := s.origin
+	if len(args) == 2 {
+		o, err := s.absolute(args[1].value)
+		if err != nil {
+			return err
+		}
+
+		origin = o
+	}
+
+	f, err := s.parser.open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// NOTE: the origin and the owner go back to their previous values after
+	// an included file, as stated in RFC 1035 section 5.1.
+	savedOrigin, savedOwner := s.origin, s.lastOwner
+	s.origin = origin
+
+	err = s.parse(f, name, depth+1)
+
+	s.origin, s.lastOwner = savedOrigin, savedOwner
+	return err
+}
+
+func (s *parseState) setContent(r *globodns.Record, rdata []token) error {
+	if len(rdata) == 0 {
+		return fmt.Errorf("missing data of %s record", r.Type)
+	}
+
+	values := make([]string, len(rdata))
+	for i, t := range rdata {
+		values[i] = t.value
+	}
+
+	switch r.Type {
+	case "TXT", "SPF":
+		r.Content = strings.Join(values, "")
+
+	case "MX":
+		if len(values) != 2 {
+			return fmt.Errorf("MX record must have preference and exchange")
+		}
+
+		prio, err := strconv.Atoi(values[0])
+		if err != nil {
+			return fmt.Errorf("invalid MX preference %q", values[0])
+		}
+
+		r.Prio = &prio
+		r.Content = s.target(values[1])
+
+	case "SRV":
+		if len(values) != 4 {
+			return fmt.Errorf("SRV record must have priority, weight, port and target")
+		}
+
+		prio, err := strconv.Atoi(values[0])
+		if err != nil {
+			return fmt.Errorf("invalid SRV priority %q", values[0])
+		}
+
+		r.Prio = &prio
+		r.Content = strings.Join([]string{values[1], values[2], s.target(values[3])}, " ")
+
+	case "CNAME", "NS", "PTR":
+		if len(values) != 1 {
+			return fmt.Errorf("%s record must have a single target", r.Type)
+		}
+
+		r.Content = s.target(values[0])
+
+	case "SOA":
+		if len(values) != 7 {
+			return fmt.Errorf("SOA record must have 7 fields")
+		}
+
+		values[0], values[1] = s.target(values[0]), s.target(values[1])
+
+		// NOTE: GloboDNS takes the timers in seconds only, not in BIND units.
+		for i, field := range []string{"refresh", "retry", "expire", "minimum"} {
+			n, err := parseTTL(values[3+i])
+			if err != nil {
+				return fmt.Errorf("invalid SOA %s %q", field, values[3+i])
+			}
+
+			values[3+i] = strconv.Itoa(n)
+		}
+
+		r.Content = strings.Join(values, " ")
+
+	default:
+		r.Content = strings.Join(values, " ")
+	}
+
+	return nil
+}
+
+// target returns a domain name found in the record data as it should be sent
+// to GloboDNS: names relative to the zone are kept as they are, while the
+// ones relative to another origin are made absolute.
+func (s *parseState) target(name string) string {
+	if name == "@" {
+		if s.origin == s.zone {
+			return name
+		}
+
+		return s.origin
+	}
+
+	if strings.HasSuffix(name, ".") || s.origin == s.zone {
+		return name
+	}
+
+	return name + "." + s.origin
+}
+
+func (s *parseState) absolute(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("empty domain name")
+	}
+
+	if name == "@" {
+		return s.origin, nil
+	}
+
+	if strings.HasSuffix(name, ".") {
+		return strings.ToLower(name), nil
+	}
+
+	return strings.ToLower(name + "." + s.origin), nil
+}
+
+func (s *parseState) relative(name string) (string, error) {
+	if name == s.zone {
+		return "@", nil
+	}
+
+	if !strings.HasSuffix(name, "."+s.zone) {
+		return "", fmt.Errorf("%s is out of zone %s", name, s.zone)
+	}
+
+	return strings.TrimSuffix(name, "."+s.zone), nil
+}
+
+func isClass(s string) bool {
+	switch strings.ToUpper(s) {
+	case "IN", "CS", "CH", "HS":
+		return true
+	}
+
+	return false
+}
+
+// parseTTL parses TTLs either as seconds or using BIND units, e.g. "1h30m".
+func parseTTL(s string) (int, error) {
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+		return n, nil
+	}
+
+	var total, current int
+	var digits bool
+
+	for _, c := range strings.ToLower(s) {
+		if c >= '0' && c <= '9' {
+			current = current*10 + int(c-'0')
+			digits = true
+			continue
+		}
+
+		if !digits {
+			return 0, fmt.Errorf("invalid TTL %q", s)
+		}
+
+		switch c {
+		case 's':
+			total += current
+		case 'm':
+			total += current * 60
+		case 'h':
+			total += current * 60 * 60
+		case 'd':
+			total += current * 24 * 60 * 60
+		case 'w':
+			total += current * 7 * 24 * 60 * 60
+		default:
+			return 0, fmt.Errorf("invalid TTL %q", s)
+		}
+
+		current, digits = 0, false
+	}
+
+	if digits {
+		return 0, fmt.Errorf("invalid TTL %q", s)
+	}
+
+	return total, nil
+}
+
+type token struct {
+	value  string
+	quoted bool
+}
+
+type entry struct {
+	tokens     []token
+	blankOwner bool
+	line       int
+}
+
+// lexer splits a master file into entries, joining lines enclosed by
+// parentheses and dropping comments.
+type lexer struct {
+	r    *bufio.Reader
+	line int
+}
+
+func newLexer(r io.Reader) *lexer {
+	return &lexer{r: bufio.NewReader(r), line: 1}
+}
+
+func (l *lexer) next() (*entry, error) {
+	for {
+		e, err := l.readEntry()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(e.tokens) > 0 {
+			return e, nil
+		}
+	}
+}
+
+func (l *lexer) readEntry() (*entry, error) {
+	e := &entry{line: l.line}
+
+	var (
+		sb      strings.Builder
+		inToken bool
+		quoted  bool
+		inQuote bool
+		parens  int
+	)
+
+	atStart := true
+
+	flush := func() {
+		if inToken {
+			e.tokens = append(e.tokens, token{value: sb.String(), quoted: quoted})
+		}
+
+		sb.Reset()
+		inToken, quoted = false, false
+	}
+
+	for {
+		c, err := l.r.ReadByte()
+		if err == io.EOF {
+			if inQuote {
+				return nil, fmt.Errorf("unterminated quoted string")
+			}
+
+			if parens > 0 {
+				return nil, fmt.Errorf("unbalanced parentheses")
+			}
+
+			flush()
+			if len(e.tokens) == 0 {
+				return nil, io.EOF
+			}
+
+			return e, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if atStart {
+			atStart = false
+			if c == ' ' || c == '\t' {
+				e.blankOwner = true
+			}
+		}
+
+		if inQuote {
+			switch c {
+			case '"':
+				inQuote = false
+			case '\\':
+				v, err := l.readEscape()
+				if err != nil {
+					return nil, err
+				}
+
+				sb.WriteByte(v)
+			case '\n':
+				l.line++
+				sb.WriteByte(c)
+			default:
+				sb.WriteByte(c)
+			}
+
+			continue
+		}
+
+		switch c {
+		case '"':
+			inToken, quoted, inQuote = true, true, true
+
+		case '\\':
+			v, err := l.readEscape()
+			if err != nil {
+				return nil, err
+			}
+
+			inToken = true
+			sb.WriteByte(v)
+
+		case ';':
+			err := l.skipComment()
+			if err == io.EOF {
+				continue
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			// NOTE: gives the line break back, so it ends the entry below.
+			if err = l.r.UnreadByte(); err != nil {
+				return nil, err
+			}
+
+		case '(':
+			flush()
+			parens++
+
+		case ')':
+			if parens == 0 {
+				return nil, fmt.Errorf("unbalanced parentheses")
+			}
+
+			flush()
+			parens--
+
+		case ' ', '\t', '\r':
+			flush()
+
+		case '\n':
+			l.line++
+			flush()
+
+			if parens == 0 {
+				return e, nil
+			}
+
+		default:
+			inToken = true
+			sb.WriteByte(c)
+		}
+	}
+}
+
+func (l *lexer) skipComment() error {
+	for {
+		c, err := l.r.ReadByte()
+		if err != nil {
+			return err
+		}
+
+		if c == '\n' {
+			return nil
+		}
+	}
+}
+
+// readEscape reads either "\X" or "\DDD" sequences, after the backslash.
+func (l *lexer) readEscape() (byte, error) {
+	c, err := l.r.ReadByte()
+	if err != nil {
+		return 0, fmt.Errorf("unterminated escape sequence")
+	}
+
+	if c < '0' || c > '9' {
+		return c, nil
+	}
+
+	digits := []byte{c}
+	for i := 0; i < 2; i++ {
+		d, err := l.r.ReadByte()
+		if err != nil || d < '0' || d > '9' {
+			return 0, fmt.Errorf("invalid escape sequence")
+		}
+
+		digits = append(digits, d)
+	}
+
+	n, err := strconv.Atoi(string(digits))
+	if err != nil || n > 255 {
+		return 0, fmt.Errorf("invalid escape sequence \\%s", digits)
+	}
+
+	return byte(n), nil
+}
@@ -0,0 +1,177 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zonefile_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/zonefile"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		domain        globodns.Domain
+		zone          string
+		expected      []globodns.Record
+		expectedError string
+	}{
+		"domain without name": {
+			expectedError: "zonefile: domain name cannot be empty",
+		},
+
+		"full zone": {
+			domain: globodns.Domain{ID: 100, Name: "example.com", TTL: globodns.StringPointer("3600")},
+			zone: `$ORIGIN example.com.
+$TTL 1h
+; the SOA record spans multiple lines
+@   IN  SOA ns1.example.com. hostmaster.example.com. (
+            2021102901 ; serial
+            3h         ; refresh
+            1h         ; retry
+            1w         ; expire
+            1h )       ; minimum
+    IN  NS  ns1
+    IN  MX  10 mail.example.com.
+www 300 IN  A   169.196.100.100
+        IN  A   169.196.100.101
+mail    A   169.196.100.102
+WWW2.EXAMPLE.COM. IN CNAME www
+_sip._tcp IN SRV 10 5 5060 sip
+@ 60 IN TXT "v=spf1 include:\"example.org\" -all"
+dkim IN TXT ( "first part;"
+              "second part" )
+$ORIGIN internal.example.com.
+db  IN  A   10.0.0.1
+api IN  CNAME db
+`,
+			expected: []globodns.Record{
+				{DomainID: 100, Name: "@", Type: "SOA", Content: "ns1.example.com. hostmaster.example.com. 2021102901 10800 3600 604800 3600"},
+				{DomainID: 100, Name: "@", Type: "NS", Content: "ns1"},
+				{DomainID: 100, Name: "@", Type: "MX", Content: "mail.example.com.", Prio: globodns.IntPointer(10)},
+				{DomainID: 100, Name: "www", Type: "A", Content: "169.196.100.100", TTL: globodns.StringPointer("300")},
+				{DomainID: 100, Name: "www", Type: "A", Content: "169.196.100.101"},
+				{DomainID: 100, Name: "mail", Type: "A", Content: "169.196.100.102"},
+				{DomainID: 100, Name: "www2", Type: "CNAME", Content: "www"},
+				{DomainID: 100, Name: "_sip._tcp", Type: "SRV", Content: "5 5060 sip", Prio: globodns.IntPointer(10)},
+				{DomainID: 100, Name: "@", Type: "TXT", Content: `v=spf1 include:"example.org" -all`, TTL: globodns.StringPointer("60")},
+				{DomainID: 100, Name: "dkim", Type: "TXT", Content: "first part;second part"},
+				{DomainID: 100, Name: "db.internal", Type: "A", Content: "10.0.0.1"},
+				{DomainID: 100, Name: "api.internal", Type: "CNAME", Content: "db.internal.example.com."},
+			},
+		},
+
+		"default TTL different from the domain one": {
+			domain: globodns.Domain{Name: "example.com"},
+			zone: `$TTL 300
+www IN A 169.196.100.100`,
+			expected: []globodns.Record{
+				{Name: "www", Type: "A", Content: "169.196.100.100", TTL: globodns.StringPointer("300")},
+			},
+		},
+
+		"record out of zone": {
+			domain:        globodns.Domain{Name: "example.com"},
+			zone:          "www.example.org. IN A 169.196.100.100\n",
+			expectedError: "zonefile: <input>:1: www.example.org. is out of zone example.com.",
+		},
+
+		"unsupported class": {
+			domain:        globodns.Domain{Name: "example.com"},
+			zone:          "\n\nversion CH TXT \"9.16\"\n",
+			expectedError: "zonefile: <input>:3: unsupported class CH",
+		},
+
+		"unterminated quoted string": {
+			domain:        globodns.Domain{Name: "example.com"},
+			zone:          `www IN TXT "some text`,
+			expectedError: "zonefile: <input>:1: unterminated quoted string",
+		},
+
+		"unbalanced parentheses": {
+			domain:        globodns.Domain{Name: "example.com"},
+			zone:          "@ IN SOA ns1 hostmaster ( 1 2 3 4 5\n",
+			expectedError: "zonefile: <input>:2: unbalanced parentheses",
+		},
+
+		"blank owner without previous one": {
+			domain:        globodns.Domain{Name: "example.com"},
+			zone:          "  IN A 169.196.100.100\n",
+			expectedError: "zonefile: <input>:1: missing owner name",
+		},
+
+		"invalid SOA timer": {
+			domain:        globodns.Domain{Name: "example.com"},
+			zone:          "@ IN SOA ns1 hostmaster 1 3h 1x 1w 1h\n",
+			expectedError: `zonefile: <input>:1: invalid SOA retry "1x"`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := zonefile.Parse(strings.NewReader(tt.zone), tt.domain)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestParseFile_Include(t *testing.T) {
+	dir, err := ioutil.TempDir("", "globodns-zonefile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "db.example.com"), []byte(`$ORIGIN example.com.
+www IN A 169.196.100.100
+$INCLUDE hosts.internal internal.example.com.
+    IN A 169.196.100.101
+`), 0600)
+	require.NoError(t, err)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "hosts.internal"), []byte(`db IN A 10.0.0.1
+@ IN A 10.0.0.254
+`), 0600)
+	require.NoError(t, err)
+
+	got, err := zonefile.ParseFile(filepath.Join(dir, "db.example.com"), globodns.Domain{Name: "example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []globodns.Record{
+		{Name: "www", Type: "A", Content: "169.196.100.100"},
+		{Name: "db.internal", Type: "A", Content: "10.0.0.1"},
+		{Name: "internal", Type: "A", Content: "10.0.0.254"},
+		{Name: "www", Type: "A", Content: "169.196.100.101"},
+	}, got)
+}
+
+func TestParse_RoundTrip(t *testing.T) {
+	domain := globodns.Domain{Name: "example.com", TTL: globodns.StringPointer("3600")}
+	records := []globodns.Record{
+		{Name: "@", Type: "SOA", Content: "ns1.example.com. hostmaster.example.com. 2021102901 10800 3600 604800 3600"},
+		{Name: "@", Type: "NS", Content: "ns1.example.com."},
+		{Name: "@", Type: "MX", Content: "mail", Prio: globodns.IntPointer(10), TTL: globodns.StringPointer("86400")},
+		{Name: "dkim", Type: "TXT", Content: strings.Repeat(`a"\`, 100)},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, zonefile.Write(&buf, domain, records))
+
+	got, err := zonefile.Parse(&buf, domain)
+	require.NoError(t, err)
+
+	assert.Equal(t, records, got)
+}
@@ -0,0 +1,154 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zonefile
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+)
+
+// maxStringLength is the maximum length of a single character-string in TXT
+// records, as defined by RFC 1035 section 3.3.
+const maxStringLength = 255
+
+// Write writes the master file (RFC 1035 section 5) of domain d with the
+// given records, which must include the SOA one. Records are written in the
+// same order, except for SOA and NS ones which come first.
+func Write(w io.Writer, d globodns.Domain, records []globodns.Record) error {
+	if d.Name == "" {
+		return fmt.Errorf("zonefile: domain name cannot be empty")
+	}
+
+	ordered, err := orderRecords(d, records)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+
+	fmt.Fprintf(tw, "$ORIGIN %s\n", fqdn(d.Name))
+
+	if ttl := d.GetTTL(); ttl != nil {
+		fmt.Fprintf(tw, "$TTL %d\n", *ttl)
+	}
+
+	fmt.Fprintln(tw)
+
+	for _, r := range ordered {
+		// NOTE: records without TTL are left to $TTL, so they still follow
+		// the domain TTL once parsed back.
+		ttl := ""
+		if n := r.GetTTL(); n != nil {
+			ttl = strconv.Itoa(*n)
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\tIN\t%s\t%s\n", owner(r.Name), ttl, strings.ToUpper(r.Type), RData(r))
+	}
+
+	return tw.Flush()
+}
+
+func orderRecords(d globodns.Domain, records []globodns.Record) ([]globodns.Record, error) {
+	var soa, ns, others []globodns.Record
+
+	for _, r := range records {
+		switch strings.ToUpper(r.Type) {
+		case "SOA":
+			soa = append(soa, r)
+		case "NS":
+			ns = append(ns, r)
+		default:
+			others = append(others, r)
+		}
+	}
+
+	if len(soa) != 1 {
+		return nil, fmt.Errorf("zonefile: domain %s must have exactly one SOA record, found %d", d.Name, len(soa))
+	}
+
+	return append(append(soa, ns...), others...), nil
+}
+
+func owner(name string) string {
+	if name == "" {
+		return "@"
+	}
+
+	return name
+}
+
+// RData returns the content of r formatted as the RDATA field of a master
+// file entry, quoting TXT strings and prepending the priority of MX and SRV
+// records.
+func RData(r globodns.Record) string {
+	switch strings.ToUpper(r.Type) {
+	case "TXT", "SPF":
+		return quote(r.Content)
+
+	case "MX", "SRV":
+		if r.Prio != nil {
+			return fmt.Sprintf("%d %s", *r.Prio, r.Content)
+		}
+	}
+
+	return r.Content
+}
+
+// quote formats content as one or more quoted character-strings, splitting
+// it when longer than allowed in a single one. Content already quoted is
+// assumed to be properly formatted.
+func quote(content string) string {
+	if len(content) >= 2 && strings.HasPrefix(content, `"`) && strings.HasSuffix(content, `"`) {
+		return content
+	}
+
+	var parts []string
+	for {
+		n := len(content)
+		if n > maxStringLength {
+			n = maxStringLength
+		}
+
+		parts = append(parts, `"`+escape(content[:n])+`"`)
+		content = content[n:]
+
+		if content == "" {
+			break
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func escape(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case c < ' ' || c > '~':
+			fmt.Fprintf(&sb, "\\%03d", c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return sb.String()
+}
+
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+
+	return name + "."
+}
@@ -0,0 +1,96 @@
+// Copyright 2021 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package zonefile_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	globodns "github.com/tsuru/go-globodnsclient"
+	"github.com/tsuru/go-globodnsclient/zonefile"
+)
+
+func TestWrite(t *testing.T) {
+	soa := globodns.Record{Name: "@", Type: "SOA", Content: "ns1.example.com. hostmaster.example.com. 2021102901 10800 3600 604800 3600"}
+
+	tests := map[string]struct {
+		domain        globodns.Domain
+		records       []globodns.Record
+		expected      string
+		expectedError string
+	}{
+		"domain without name": {
+			expectedError: "zonefile: domain name cannot be empty",
+		},
+
+		"domain without SOA": {
+			domain:        globodns.Domain{Name: "example.com"},
+			records:       []globodns.Record{{Name: "www", Type: "A", Content: "169.196.100.100"}},
+			expectedError: "zonefile: domain example.com must have exactly one SOA record, found 0",
+		},
+
+		"writing a zone": {
+			domain: globodns.Domain{Name: "example.com", TTL: globodns.StringPointer("3600")},
+			records: []globodns.Record{
+				{Name: "www", Type: "A", Content: "169.196.100.100"},
+				{Name: "@", Type: "MX", Content: "mail", Prio: globodns.IntPointer(10), TTL: globodns.StringPointer("86400")},
+				{Name: "@", Type: "NS", Content: "ns1.example.com."},
+				soa,
+				{Name: "_sip._tcp", Type: "SRV", Content: "5 5060 sip", Prio: globodns.IntPointer(10)},
+				{Name: "@", Type: "TXT", Content: `v=spf1 include:"example.org" -all`, TTL: globodns.StringPointer("60")},
+				{Name: "quoted", Type: "txt", Content: `"already" "quoted"`},
+			},
+			expected: `$ORIGIN example.com.
+$TTL 3600
+
+@			IN	SOA	ns1.example.com. hostmaster.example.com. 2021102901 10800 3600 604800 3600
+@			IN	NS	ns1.example.com.
+www			IN	A	169.196.100.100
+@		86400	IN	MX	10 mail
+_sip._tcp		IN	SRV	10 5 5060 sip
+@		60	IN	TXT	"v=spf1 include:\"example.org\" -all"
+quoted			IN	TXT	"already" "quoted"
+`,
+		},
+
+		"domain without TTL": {
+			domain:  globodns.Domain{Name: "example.com."},
+			records: []globodns.Record{soa, {Name: "www", Type: "A", Content: "169.196.100.100"}},
+			expected: `$ORIGIN example.com.
+
+@		IN	SOA	ns1.example.com. hostmaster.example.com. 2021102901 10800 3600 604800 3600
+www		IN	A	169.196.100.100
+`,
+		},
+
+		"long TXT content": {
+			domain:  globodns.Domain{Name: "example.com"},
+			records: []globodns.Record{soa, {Name: "dkim", Type: "TXT", Content: strings.Repeat("a", 300)}},
+			expected: `$ORIGIN example.com.
+
+@		IN	SOA	ns1.example.com. hostmaster.example.com. 2021102901 10800 3600 604800 3600
+dkim		IN	TXT	"` + strings.Repeat("a", 255) + `" "` + strings.Repeat("a", 45) + `"
+`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := zonefile.Write(&buf, tt.domain, tt.records)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+}